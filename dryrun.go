@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yanskun/gh-dispatch/internal/shellquote"
+	"github.com/yanskun/gh-dispatch/internal/workflow"
+)
+
+// dispatchPreview は dispatch で送信されるリクエストと、同じリクエストを送る gh api コマンドを返します
+func dispatchPreview(host string, params workflow.DispatchParams) (string, error) {
+	params.ReturnRunDetails = supportsRunDetails(host)
+	endpoint, body, err := workflow.DispatchRequest(params)
+	if err != nil {
+		return "", err
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, body, "", "  "); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "POST %s\n\n%s\n\n", endpoint, pretty.String())
+	fmt.Fprintf(&b, "Equivalent command:\n  %s", shellquote.Join(ghAPIArgs(host, endpoint, params)...))
+	return b.String(), nil
+}
+
+// ghAPIArgs は dispatch と同じリクエストを送る gh api コマンドの引数を返します
+func ghAPIArgs(host, endpoint string, params workflow.DispatchParams) []string {
+	args := []string{"gh", "api", "--method", "POST"}
+	if host != "" && !strings.EqualFold(host, "github.com") {
+		args = append(args, "--hostname", host)
+	}
+	args = append(args, endpoint, "-f", "ref="+params.Ref)
+
+	keys := make([]string, 0, len(params.Inputs))
+	for key := range params.Inputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	// inputs はすべて文字列として送るため、型変換しない -f を使う
+	for _, key := range keys {
+		args = append(args, "-f", fmt.Sprintf("inputs[%s]=%s", key, params.Inputs[key]))
+	}
+
+	if params.ReturnRunDetails {
+		args = append(args, "-F", "return_run_details=true")
+	}
+	return args
+}
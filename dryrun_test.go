@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yanskun/gh-dispatch/internal/workflow"
+)
+
+func TestGhAPIArgs(t *testing.T) {
+	const endpoint = "repos/user/repo/actions/workflows/deploy.yml/dispatches"
+
+	tests := []struct {
+		name   string
+		host   string
+		params workflow.DispatchParams
+		want   []string
+	}{
+		{
+			name: "github.com sorts inputs and asks for run details",
+			host: "github.com",
+			params: workflow.DispatchParams{
+				Ref:              "main",
+				Inputs:           map[string]string{"version": "v1.2.3", "environment": "staging", "dry_run": "true"},
+				ReturnRunDetails: true,
+			},
+			want: []string{
+				"gh", "api", "--method", "POST", endpoint,
+				"-f", "ref=main",
+				"-f", "inputs[dry_run]=true",
+				"-f", "inputs[environment]=staging",
+				"-f", "inputs[version]=v1.2.3",
+				"-F", "return_run_details=true",
+			},
+		},
+		{
+			name: "other hosts are passed with --hostname",
+			host: "ghes.example.com",
+			params: workflow.DispatchParams{
+				Ref:    "release/1.0",
+				Inputs: map[string]string{"note": "it's done"},
+			},
+			want: []string{
+				"gh", "api", "--method", "POST", "--hostname", "ghes.example.com", endpoint,
+				"-f", "ref=release/1.0",
+				"-f", "inputs[note]=it's done",
+			},
+		},
+		{
+			name:   "no inputs",
+			host:   "",
+			params: workflow.DispatchParams{Ref: "v1.0.0"},
+			want:   []string{"gh", "api", "--method", "POST", endpoint, "-f", "ref=v1.0.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ghAPIArgs(tt.host, endpoint, tt.params); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ghAPIArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatchPreview(t *testing.T) {
+	params := workflow.DispatchParams{
+		Owner:        "user",
+		Repo:         "repo",
+		WorkflowFile: "deploy.yml",
+		Ref:          "main",
+		Inputs:       map[string]string{"note": "it's done", "environment": "staging"},
+	}
+
+	tests := []struct {
+		name        string
+		host        string
+		wantCommand string
+	}{
+		{
+			name:        "github.com",
+			host:        "github.com",
+			wantCommand: `gh api --method POST repos/user/repo/actions/workflows/deploy.yml/dispatches -f ref=main -f 'inputs[environment]=staging' -f 'inputs[note]=it'\''s done' -F return_run_details=true`,
+		},
+		{
+			name:        "GitHub Enterprise Server",
+			host:        "ghes.example.com",
+			wantCommand: `gh api --method POST --hostname ghes.example.com repos/user/repo/actions/workflows/deploy.yml/dispatches -f ref=main -f 'inputs[environment]=staging' -f 'inputs[note]=it'\''s done'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dispatchPreview(tt.host, params)
+			if err != nil {
+				t.Fatalf("dispatchPreview() unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(got, "POST repos/user/repo/actions/workflows/deploy.yml/dispatches\n") {
+				t.Errorf("dispatchPreview() = %q, want the request line first", got)
+			}
+			if !strings.HasSuffix(got, "Equivalent command:\n  "+tt.wantCommand) {
+				t.Errorf("dispatchPreview() = %q, want command %q", got, tt.wantCommand)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yanskun/gh-dispatch/internal/environment"
+	"github.com/yanskun/gh-dispatch/internal/retry"
+	"github.com/yanskun/gh-dispatch/internal/workflow"
+)
+
+// environmentsLoadedMsg は環境一覧の取得結果です
+type environmentsLoadedMsg struct {
+	environments []environment.Environment
+	err          error
+}
+
+// loadEnvironments はリポジトリの環境一覧を取得するコマンドを返します
+func loadEnvironments(client *retry.Client, owner, repo string) tea.Cmd {
+	return func() tea.Msg {
+		envs, err := environment.Fetch(client, owner, repo)
+		return environmentsLoadedMsg{environments: envs, err: err}
+	}
+}
+
+// needsEnvironments は type: environment の input を持つワークフローがあるかを返します
+func needsEnvironments(workflows []list.Item) bool {
+	for _, li := range workflows {
+		if wf, ok := li.(item); ok && workflow.HasEnvironmentInput(wf.inputs) {
+			return true
+		}
+	}
+	return false
+}
+
+// inputSchema は選択中のワークフローの input 定義に、環境一覧を選択肢として設定したものを返します
+func (m model) inputSchema() map[string]workflow.Input {
+	return workflow.WithEnvironments(m.selectedWorkflow.inputs, environment.Names(m.environments))
+}
+
+// applyEnvironments は inputs の入力中に環境一覧が届いた場合に、入力中の画面へ選択肢を反映します
+func (m *model) applyEnvironments() {
+	if m.state != enteringInputs && m.state != fillingForm && m.state != confirming {
+		return
+	}
+	if !workflow.HasEnvironmentInput(m.workflowInputs) {
+		return
+	}
+	m.workflowInputs = m.inputSchema()
+
+	switch m.state {
+	case enteringInputs:
+		if m.workflowInputs[m.inputKeys[m.currentInputIdx]].Type == workflow.InputTypeEnvironment {
+			m.resetInput()
+		}
+	case fillingForm:
+		for idx, f := range m.formFields {
+			if f.input.Type != workflow.InputTypeEnvironment {
+				continue
+			}
+			// 入力途中の値は選択肢の初期値として引き継ぐ
+			values := map[string]string{}
+			if value := f.value(); value != "" {
+				values[f.key] = value
+			}
+			m.formFields[idx] = newFormField(f.key, m.workflowInputs[f.key], values)
+			if idx == m.formIdx {
+				m.formFields[idx].text.Focus()
+			}
+		}
+	}
+}
+
+// environmentSummary は type: environment の input の値について、環境の保護状態を返します
+// 環境一覧にない値やそれ以外の input では空文字列を返します
+func (m model) environmentSummary(input workflow.Input, value string) string {
+	if input.Type != workflow.InputTypeEnvironment {
+		return ""
+	}
+	for _, env := range m.environments {
+		if env.Name == value {
+			return env.Summary()
+		}
+	}
+	return ""
+}
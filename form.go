@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yanskun/gh-dispatch/internal/workflow"
+)
+
+// defaultFormThreshold はフォーム画面に切り替える input 数の既定値です
+// これより多くの inputs を持つワークフローは、1 つずつ入力するウィザードの代わりにフォームで入力します
+const defaultFormThreshold = 5
+
+// formField は 1 つの input の入力欄です
+// input の type に応じた入力・検証を受け持ち、1 つずつ入力する画面とフォーム画面の両方で使います
+type formField struct {
+	key       string
+	input     workflow.Input
+	text      textinput.Model // string・number 型の入力欄
+	choiceIdx int             // choice 型の選択位置
+	boolValue bool            // boolean 型の値
+	err       string          // 入力値の検証エラー
+}
+
+// newFormField は input の入力欄を作成します
+// 入力済みの値があればそれを、なければデフォルト値を初期値にします
+func newFormField(key string, input workflow.Input, values map[string]string) formField {
+	f := formField{key: key, input: input, text: newTextInput(input.Default)}
+	f.text.Blur()
+
+	initial := input.Default
+	if value, ok := values[key]; ok {
+		initial = value
+		f.text.SetValue(value)
+		// 選択肢にない値も選択肢に置き換える前の値で検証してエラーを表示する
+		if err := workflow.ValidateInput(input, value); err != nil {
+			f.err = err.Error()
+		}
+	}
+
+	// 初期値が選択肢になければ先頭を選ぶ (environment 型では未選択)
+	for idx, opt := range f.choices() {
+		if opt == initial {
+			f.choiceIdx = idx
+			break
+		}
+	}
+	f.boolValue = initial == "true"
+	return f
+}
+
+// choices は選択肢の一覧を返します
+// environment 型は API が返した順の先頭の環境が勝手に選ばれないよう、先頭に未選択 ("") を加えます
+// 必須でデフォルト値がある場合は未選択にする必要がないため加えません
+func (f formField) choices() []string {
+	if f.input.Type == workflow.InputTypeEnvironment && (!f.input.Required || f.input.Default == "") {
+		return append([]string{""}, f.input.Options...)
+	}
+	return f.input.Options
+}
+
+// choiceLabel は選択肢の表示名を返します
+func (f formField) choiceLabel(opt string) string {
+	if opt != "" {
+		return opt
+	}
+	if f.input.Required {
+		return "(select an environment)"
+	}
+	return "(none)"
+}
+
+// value は入力された値を type に応じて返します
+func (f formField) value() string {
+	switch {
+	case isChoice(f.input):
+		return f.choices()[f.choiceIdx]
+	case f.input.Type == workflow.InputTypeBoolean:
+		return strconv.FormatBool(f.boolValue)
+	}
+
+	if f.text.Value() == "" && f.input.Default != "" {
+		return f.input.Default
+	}
+	return f.text.Value()
+}
+
+// validate は入力値を検証し、エラーを err に設定します
+func (f *formField) validate() bool {
+	f.err = ""
+	if err := workflow.ValidateInput(f.input, f.value()); err != nil {
+		f.err = err.Error()
+		return false
+	}
+	return true
+}
+
+// update は入力欄にメッセージを渡します
+// choice 型は ↑/↓ または ←/→ で選び、boolean 型は ←/→・Space・Tab で切り替えるか y/n で指定します
+func (f *formField) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	keyMsg, isKey := msg.(tea.KeyMsg)
+
+	switch {
+	case isChoice(f.input):
+		if !isKey {
+			return nil
+		}
+		switch keyMsg.String() {
+		case "up", "k", "left", "h":
+			if f.choiceIdx > 0 {
+				f.choiceIdx--
+			}
+		case "down", "j", "right", "l":
+			if f.choiceIdx < len(f.choices())-1 {
+				f.choiceIdx++
+			}
+		}
+	case f.input.Type == workflow.InputTypeBoolean:
+		if !isKey {
+			return nil
+		}
+		switch keyMsg.String() {
+		case "left", "right", "h", "l", "tab", " ":
+			f.boolValue = !f.boolValue
+		case "y", "t":
+			f.boolValue = true
+		case "n", "f":
+			f.boolValue = false
+		}
+	default:
+		var ti textinput.Model
+		ti, cmd = f.text.Update(msg)
+		// number 型は数値として解釈できる文字列になる編集のみ受け付ける
+		if f.input.Type == workflow.InputTypeNumber && !isNumberPrefix(ti.Value()) {
+			return nil
+		}
+		f.text = ti
+	}
+
+	// エラー表示中の値が修正されたらすぐにエラーを消す
+	if f.err != "" {
+		f.validate()
+	}
+	return cmd
+}
+
+// formMode は現在のワークフローの inputs をフォーム画面で入力するかを返します
+func (m model) formMode() bool {
+	return len(m.inputKeys) > m.formThreshold
+}
+
+// startForm はすべての inputs を 1 画面で入力するフォームを開始します
+func (m *model) startForm(focus int) {
+	m.state = fillingForm
+	m.formFields = make([]formField, len(m.inputKeys))
+	for idx, key := range m.inputKeys {
+		m.formFields[idx] = newFormField(key, m.workflowInputs[key], m.userInputs)
+	}
+	m.formIdx = min(max(focus, 0), len(m.formFields)-1)
+	m.formFields[m.formIdx].text.Focus()
+}
+
+// moveFormFocus はフォーカスを delta だけ移動します。離れる入力欄はその時点で検証します
+func (m *model) moveFormFocus(delta int) {
+	current := &m.formFields[m.formIdx]
+	current.validate()
+	current.text.Blur()
+
+	m.formIdx = (m.formIdx + delta + len(m.formFields)) % len(m.formFields)
+	m.formFields[m.formIdx].text.Focus()
+}
+
+// saveFormValues はフォームの値のうち妥当なものを userInputs に保存し、すべて妥当かを返します
+func (m *model) saveFormValues() bool {
+	valid := true
+	for idx := range m.formFields {
+		f := &m.formFields[idx]
+		if f.validate() {
+			m.userInputs[f.key] = f.value()
+		} else {
+			valid = false
+		}
+	}
+	return valid
+}
+
+// updateForm はフォーム画面でのキー操作を処理します
+func (m *model) updateForm(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "tab", "down":
+		m.moveFormFocus(1)
+		return nil
+	case "shift+tab", "up":
+		m.moveFormFocus(-1)
+		return nil
+	case "enter":
+		if m.saveFormValues() {
+			m.state = confirming
+			return nil
+		}
+		// 最初の不正な入力欄へフォーカスを移す
+		for idx, f := range m.formFields {
+			if f.err != "" {
+				m.moveFormFocus(idx - m.formIdx)
+				break
+			}
+		}
+		return nil
+	case "esc":
+		m.saveFormValues()
+		return m.showRefPicker()
+	}
+	return m.formFields[m.formIdx].update(msg)
+}
+
+// formView はフォーム画面を描画します
+func (m model) formView() string {
+	var output strings.Builder
+
+	output.WriteString(titleStyle.Render(fmt.Sprintf("Workflow Inputs: %s", m.selectedWorkflow.title)))
+	output.WriteString("\n\n")
+	output.WriteString(labelStyle.Render(refLabel(m.selectedBranch.refKind) + ": "))
+	output.WriteString(valueStyle.Render(m.selectedBranch.title))
+	output.WriteString("\n\n")
+
+	width := 0
+	for _, f := range m.formFields {
+		width = max(width, len(f.key))
+	}
+
+	for idx, f := range m.formFields {
+		focused := idx == m.formIdx
+		if focused {
+			output.WriteString(inputStyle.Render("> "))
+		} else {
+			output.WriteString("  ")
+		}
+
+		name := fmt.Sprintf("%-*s", width, f.key)
+		if focused {
+			output.WriteString(valueStyle.Render(name))
+		} else {
+			output.WriteString(labelStyle.Render(name))
+		}
+		if f.input.Required {
+			output.WriteString(requiredStyle.Render(" *"))
+		} else {
+			output.WriteString("  ")
+		}
+		output.WriteString("  ")
+		output.WriteString(f.valueView(focused))
+		if summary := m.environmentSummary(f.input, f.value()); summary != "" {
+			output.WriteString(labelStyle.Render("  (" + summary + ")"))
+		}
+		output.WriteString("\n")
+
+		indent := strings.Repeat(" ", width+6)
+		if f.err != "" {
+			output.WriteString(indent + errorStyle.Render("✗ "+f.err))
+			output.WriteString("\n")
+		}
+		if focused && f.input.Description != "" {
+			output.WriteString(indent + labelStyle.Render(f.input.Description))
+			output.WriteString("\n")
+		}
+	}
+
+	output.WriteString("\n")
+	output.WriteString(requiredStyle.Render("*"))
+	output.WriteString(labelStyle.Render(" required"))
+	output.WriteString("\n")
+	output.WriteString(hintStyle.Render("Tab/Shift+Tab or ↑/↓: move · ←/→: change choice · Enter: review · Esc: back · Ctrl+C: cancel"))
+
+	return docStyle.Render(output.String())
+}
+
+// valueView は入力欄を type に応じて描画します
+func (f formField) valueView(focused bool) string {
+	switch {
+	case isChoice(f.input):
+		opt := f.choiceLabel(f.choices()[f.choiceIdx])
+		if focused {
+			return inputStyle.Render("‹ " + opt + " ›")
+		}
+		return valueStyle.Render(opt)
+	case f.input.Type == workflow.InputTypeBoolean:
+		var parts []string
+		for _, v := range []bool{true, false} {
+			if v == f.boolValue {
+				parts = append(parts, inputStyle.Render(fmt.Sprintf("[x] %t", v)))
+			} else {
+				parts = append(parts, labelStyle.Render(fmt.Sprintf("[ ] %t", v)))
+			}
+		}
+		return strings.Join(parts, "  ")
+	}
+	return textInputView(f.text)
+}
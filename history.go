@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/yanskun/gh-dispatch/internal/history"
+	"github.com/yanskun/gh-dispatch/internal/workflow"
+)
+
+// repoHistory は現在のリポジトリの実行履歴を新しい順に返します
+func repoHistory(ctx *repoContext) ([]history.Entry, error) {
+	entries, err := history.Load(history.DefaultPath())
+	if err != nil {
+		return nil, err
+	}
+	return history.ForRepo(entries, ctx.fullName()), nil
+}
+
+// sortedInputs は inputs を key=value 形式でキー順に並べた文字列を返します
+func sortedInputs(inputs map[string]string) string {
+	keys := make([]string, 0, len(inputs))
+	for key := range inputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return formatInputs(inputs, keys)
+}
+
+// historyCommand は実行履歴を一覧表示する `gh dispatch history` を処理します
+func historyCommand(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	limit := fs.Int("limit", 20, "maximum number of entries to show")
+	var target repoFlags
+	target.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gh dispatch history [--limit N] [--repo [HOST/]OWNER/REPO] [--hostname HOST]\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	ctx, err := loadRepoContext(target)
+	if err != nil {
+		return err
+	}
+
+	entries, err := repoHistory(ctx)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No dispatch history for %s/%s.\n", ctx.owner, ctx.repo)
+		return nil
+	}
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[:*limit]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tTIME\tWORKFLOW\tREF\tINPUTS")
+	for idx, e := range entries {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", idx+1, e.Time.Local().Format("2006-01-02 15:04"), e.Workflow, e.Ref, sortedInputs(e.Inputs))
+	}
+	w.Flush()
+
+	fmt.Println("\nTo dispatch one of them again, run:\n  gh dispatch again <#>")
+	return nil
+}
+
+// againCommand は履歴のワークフロー実行を確認画面から再実行する `gh dispatch again` を処理します
+func againCommand(args []string) error {
+	fs := flag.NewFlagSet("again", flag.ExitOnError)
+	watch := fs.Bool("watch", false, "watch the jobs of the created run and exit with a status matching its conclusion")
+	formThreshold := fs.Int("form-threshold", defaultFormThreshold, "enter inputs in a single-screen form when a workflow has more than `N` inputs")
+	var target repoFlags
+	target.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gh dispatch again [--watch] [--form-threshold N] [--repo [HOST/]OWNER/REPO] [--hostname HOST] [<#>]\n\nReplay the most recent dispatch, or the one numbered <#> in `gh dispatch history`.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	number := 1
+	if fs.NArg() > 0 {
+		n, err := strconv.Atoi(fs.Arg(0))
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid history number %q", fs.Arg(0))
+		}
+		number = n
+	}
+
+	ctx, err := loadRepoContext(target)
+	if err != nil {
+		return err
+	}
+
+	entries, err := repoHistory(ctx)
+	if err != nil {
+		return err
+	}
+	if len(entries) < number {
+		return fmt.Errorf("no dispatch #%d in the history of %s/%s", number, ctx.owner, ctx.repo)
+	}
+	entry := entries[number-1]
+
+	wf, err := workflow.FindWorkflow(ctx.workflows, entry.Workflow)
+	if err != nil {
+		return err
+	}
+
+	m, err := newModel(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 履歴の値で確認画面から始める (ワークフローの定義が変わっていれば入力画面になる)
+	m.selectedWorkflow = workflowItems([]workflow.Workflow{wf})[0].(item)
+	m.selectedBranch = item{title: entry.Ref}
+	m.prefilledInputs = entry.Inputs
+	m.watch = *watch
+	m.formThreshold = *formThreshold
+	m.startInputs()
+
+	return runProgram(ctx, m)
+}
@@ -1,7 +1,15 @@
 package branch
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
 )
 
 // RESTClient はAPIリクエストを行うためのインターフェース
@@ -9,20 +17,251 @@ type RESTClient interface {
 	Get(path string, response any) error
 }
 
+// GraphQLClient はGraphQLリクエストを行うためのインターフェース
+type GraphQLClient interface {
+	Do(query string, variables map[string]any, response any) error
+}
+
 // Branch はブランチの基本情報を表します
+// CommittedAt・Author・Default は GraphQL で取得した場合 (LoadBranches・Searcher) のみ設定されます
 type Branch struct {
+	Name        string    `json:"name"`
+	Protected   bool      `json:"protected"`
+	CommittedAt time.Time `json:"-"` // 最新コミットの日時
+	Author      string    `json:"-"` // 最新コミットの作成者
+	Default     bool      `json:"-"` // リポジトリのデフォルトブランチか
+}
+
+// Tag はタグの基本情報を表します
+type Tag struct {
 	Name string `json:"name"`
 }
 
-// FetchBranches は指定されたリポジトリのブランチ一覧を取得します
-func FetchBranches(client RESTClient, owner, repo string) ([]Branch, error) {
-	var branches []Branch
-	path := fmt.Sprintf("repos/%s/%s/branches", owner, repo)
+// ref の種類
+const (
+	KindBranch = "branch"
+	KindTag    = "tag"
+)
 
-	err := client.Get(path, &branches)
+// perPage は1回のリクエストで取得する件数 (API の上限)
+const perPage = 100
+
+// FetchBranches は指定されたリポジトリのブランチ一覧をすべてのページから取得します
+func FetchBranches(client RESTClient, owner, repo string) ([]Branch, error) {
+	branches, err := fetchAll[Branch](client, fmt.Sprintf("repos/%s/%s/branches", owner, repo))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch branches: %w", err)
 	}
+	return branches, nil
+}
+
+// FetchTags は指定されたリポジトリのタグ一覧をすべてのページから取得します
+func FetchTags(client RESTClient, owner, repo string) ([]Tag, error) {
+	tags, err := fetchAll[Tag](client, fmt.Sprintf("repos/%s/%s/tags", owner, repo))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	return tags, nil
+}
+
+// fetchAll は一覧 API を最後のページまで取得します
+func fetchAll[T any](client RESTClient, path string) ([]T, error) {
+	all := []T{}
+
+	for page := 1; ; page++ {
+		var items []T
+		err := client.Get(fmt.Sprintf("%s?per_page=%d&page=%d", path, perPage, page), &items)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, items...)
+		if len(items) < perPage {
+			return all, nil
+		}
+	}
+}
+
+// branchDetailsQuery はブランチと最新コミットの情報を取得する GraphQL クエリです
+// $query を指定するとブランチ名で絞り込み、$orderBy を指定すると並び順を変更します
+const branchDetailsQuery = `
+query($owner: String!, $repo: String!, $cursor: String, $first: Int!, $query: String, $orderBy: RefOrder) {
+  repository(owner: $owner, name: $repo) {
+    defaultBranchRef { name }
+    refs(refPrefix: "refs/heads/", first: $first, after: $cursor, query: $query, orderBy: $orderBy) {
+      totalCount
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        name
+        branchProtectionRule { id }
+        target {
+          ... on Commit {
+            committedDate
+            author { name user { login } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// branchNode は GraphQL の refs の各ノードです
+type branchNode struct {
+	Name                 string `json:"name"`
+	BranchProtectionRule *struct {
+		ID string `json:"id"`
+	} `json:"branchProtectionRule"`
+	Target struct {
+		CommittedDate time.Time `json:"committedDate"`
+		Author        struct {
+			Name string `json:"name"`
+			User *struct {
+				Login string `json:"login"`
+			} `json:"user"`
+		} `json:"author"`
+	} `json:"target"`
+}
+
+// toBranch は GraphQL のノードを Branch に変換します
+func (n branchNode) toBranch(defaultBranch string) Branch {
+	author := n.Target.Author.Name
+	if n.Target.Author.User != nil && n.Target.Author.User.Login != "" {
+		author = n.Target.Author.User.Login
+	}
+	return Branch{
+		Name:        n.Name,
+		Protected:   n.BranchProtectionRule != nil,
+		CommittedAt: n.Target.CommittedDate,
+		Author:      author,
+		Default:     n.Name == defaultBranch,
+	}
+}
+
+// branchPage は GraphQL で取得したブランチ 1 ページ分の結果です
+type branchPage struct {
+	Branches   []Branch
+	TotalCount int
+	HasNext    bool
+	EndCursor  string
+}
+
+// branchVariables は branchDetailsQuery の既定の変数を返します
+func branchVariables(owner, repo string) map[string]any {
+	return map[string]any{
+		"owner":   owner,
+		"repo":    repo,
+		"cursor":  nil,
+		"first":   perPage,
+		"query":   nil,
+		"orderBy": nil,
+	}
+}
+
+// fetchBranchPage は branchDetailsQuery を 1 回実行してブランチ 1 ページ分を取得します
+func fetchBranchPage(client GraphQLClient, variables map[string]any) (branchPage, error) {
+	var response struct {
+		Repository struct {
+			DefaultBranchRef *struct {
+				Name string `json:"name"`
+			} `json:"defaultBranchRef"`
+			Refs struct {
+				TotalCount int `json:"totalCount"`
+				PageInfo   struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []branchNode `json:"nodes"`
+			} `json:"refs"`
+		} `json:"repository"`
+	}
+	if err := client.Do(branchDetailsQuery, variables, &response); err != nil {
+		return branchPage{}, fmt.Errorf("failed to fetch branches: %w", err)
+	}
 
+	defaultBranch := ""
+	if response.Repository.DefaultBranchRef != nil {
+		defaultBranch = response.Repository.DefaultBranchRef.Name
+	}
+	refs := response.Repository.Refs
+	page := branchPage{
+		Branches:   []Branch{},
+		TotalCount: refs.TotalCount,
+		HasNext:    refs.PageInfo.HasNextPage,
+		EndCursor:  refs.PageInfo.EndCursor,
+	}
+	for _, n := range refs.Nodes {
+		page.Branches = append(page.Branches, n.toBranch(defaultBranch))
+	}
+	return page, nil
+}
+
+// fetchRemainingPages は取得済みの最初のページに続けて、最後のページまでブランチを取得します
+func fetchRemainingPages(client GraphQLClient, variables map[string]any, page branchPage) ([]Branch, error) {
+	branches := page.Branches
+	for page.HasNext {
+		variables["cursor"] = page.EndCursor
+
+		var err error
+		page, err = fetchBranchPage(client, variables)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, page.Branches...)
+	}
 	return branches, nil
 }
+
+// SortByRecent は最新コミットが新しい順にブランチを並べ替えます
+func SortByRecent(branches []Branch) {
+	sort.SliceStable(branches, func(i, j int) bool {
+		return branches[i].CommittedAt.After(branches[j].CommittedAt)
+	})
+}
+
+// ResolveRef は入力された ref がブランチまたはタグとして存在するか確認し、
+// 正規化した名前 (refs/heads/ などを除いたもの) と種類を返します
+func ResolveRef(client RESTClient, owner, repo, ref string) (string, string, error) {
+	candidates := []struct{ kind, name string }{
+		{KindBranch, ref},
+		{KindTag, ref},
+	}
+	if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+		candidates = candidates[:1]
+		candidates[0].name = name
+	} else if name, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+		candidates = candidates[1:]
+		candidates[0].name = name
+	}
+
+	for _, c := range candidates {
+		prefix := "heads"
+		if c.kind == KindTag {
+			prefix = "tags"
+		}
+		path := fmt.Sprintf("repos/%s/%s/git/ref/%s/%s", owner, repo, prefix, escapeRef(c.name))
+
+		var result struct {
+			Ref string `json:"ref"`
+		}
+		err := client.Get(path, &result)
+		if err == nil {
+			return c.name, c.kind, nil
+		}
+
+		var httpErr *api.HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+			return "", "", fmt.Errorf("failed to look up ref %q: %w", ref, err)
+		}
+	}
+
+	return "", "", fmt.Errorf("ref %q is not a branch or tag of %s/%s", ref, owner, repo)
+}
+
+// escapeRef はスラッシュを残したまま ref 名の各要素をエスケープします
+func escapeRef(ref string) string {
+	parts := strings.Split(ref, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
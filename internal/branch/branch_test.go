@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
 )
 
 // mockRESTClient は branch.RESTClient のモックです
@@ -90,3 +93,249 @@ func TestFetchBranches(t *testing.T) {
 		})
 	}
 }
+
+// pagedRESTClient はページ番号ごとに異なるレスポンスを返すモックです
+type pagedRESTClient struct {
+	Pages [][]Branch
+	Paths []string
+}
+
+func (m *pagedRESTClient) Get(path string, response any) error {
+	m.Paths = append(m.Paths, path)
+
+	var data []Branch
+	if idx := len(m.Paths) - 1; idx < len(m.Pages) {
+		data = m.Pages[idx]
+	}
+	b, _ := json.Marshal(data)
+	return json.Unmarshal(b, response)
+}
+
+func TestFetchBranchesPagination(t *testing.T) {
+	page := func(prefix string, n int) []Branch {
+		branches := make([]Branch, n)
+		for i := range branches {
+			branches[i] = Branch{Name: fmt.Sprintf("%s-%d", prefix, i)}
+		}
+		return branches
+	}
+
+	tests := []struct {
+		name      string
+		pages     [][]Branch
+		wantCount int
+		wantLast  string
+		wantPaths []string
+	}{
+		{
+			name:      "several pages",
+			pages:     [][]Branch{page("a", 100), page("b", 100), page("c", 42)},
+			wantCount: 242,
+			wantLast:  "c-41",
+			wantPaths: []string{
+				"repos/user/repo/branches?per_page=100&page=1",
+				"repos/user/repo/branches?per_page=100&page=2",
+				"repos/user/repo/branches?per_page=100&page=3",
+			},
+		},
+		{
+			name:      "last page is exactly full",
+			pages:     [][]Branch{page("a", 100), {}},
+			wantCount: 100,
+			wantLast:  "a-99",
+			wantPaths: []string{
+				"repos/user/repo/branches?per_page=100&page=1",
+				"repos/user/repo/branches?per_page=100&page=2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &pagedRESTClient{Pages: tt.pages}
+
+			got, err := FetchBranches(client, "user", "repo")
+			if err != nil {
+				t.Fatalf("FetchBranches() unexpected error: %v", err)
+			}
+
+			if len(got) != tt.wantCount {
+				t.Errorf("FetchBranches() returned %d branches, want %d", len(got), tt.wantCount)
+			}
+			if got[0].Name != "a-0" || got[len(got)-1].Name != tt.wantLast {
+				t.Errorf("FetchBranches() returned branches out of order: first %q, last %q", got[0].Name, got[len(got)-1].Name)
+			}
+			if !reflect.DeepEqual(client.Paths, tt.wantPaths) {
+				t.Errorf("FetchBranches() requested %v, want %v", client.Paths, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestFetchTags(t *testing.T) {
+	client := &pagedRESTClient{Pages: [][]Branch{{{Name: "v1.0.0"}, {Name: "v0.9.0"}}}}
+
+	got, err := FetchTags(client, "user", "repo")
+	if err != nil {
+		t.Fatalf("FetchTags() unexpected error: %v", err)
+	}
+
+	want := []Tag{{Name: "v1.0.0"}, {Name: "v0.9.0"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FetchTags() = %v, want %v", got, want)
+	}
+	if client.Paths[0] != "repos/user/repo/tags?per_page=100&page=1" {
+		t.Errorf("FetchTags() requested %v", client.Paths)
+	}
+}
+
+// routeRESTClient はパスごとに成功・失敗を返すモックです
+type routeRESTClient struct {
+	Found map[string]bool
+	Error error
+}
+
+func (m *routeRESTClient) Get(path string, response any) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	if !m.Found[path] {
+		return &api.HTTPError{StatusCode: 404, Message: "Not Found"}
+	}
+	return json.Unmarshal([]byte(`{"ref": "x"}`), response)
+}
+
+func TestResolveRef(t *testing.T) {
+	found := map[string]bool{
+		"repos/user/repo/git/ref/heads/feature/login": true,
+		"repos/user/repo/git/ref/tags/v1.0.0":         true,
+	}
+
+	tests := []struct {
+		name          string
+		ref           string
+		clientErr     error
+		wantName      string
+		wantKind      string
+		wantErrString string
+	}{
+		{name: "branch", ref: "feature/login", wantName: "feature/login", wantKind: KindBranch},
+		{name: "tag", ref: "v1.0.0", wantName: "v1.0.0", wantKind: KindTag},
+		{name: "full branch ref", ref: "refs/heads/feature/login", wantName: "feature/login", wantKind: KindBranch},
+		{name: "full tag ref", ref: "refs/tags/v1.0.0", wantName: "v1.0.0", wantKind: KindTag},
+		{name: "full ref of wrong kind", ref: "refs/tags/feature/login", wantErrString: `ref "refs/tags/feature/login" is not a branch or tag of user/repo`},
+		{name: "unknown", ref: "nope", wantErrString: `ref "nope" is not a branch or tag of user/repo`},
+		{name: "api error", ref: "main", clientErr: fmt.Errorf("network error"), wantErrString: `failed to look up ref "main": network error`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &routeRESTClient{Found: found, Error: tt.clientErr}
+
+			name, kind, err := ResolveRef(client, "user", "repo", tt.ref)
+
+			if tt.wantErrString != "" {
+				if err == nil || err.Error() != tt.wantErrString {
+					t.Errorf("ResolveRef() error = %v, want %v", err, tt.wantErrString)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ResolveRef() unexpected error: %v", err)
+			}
+			if name != tt.wantName || kind != tt.wantKind {
+				t.Errorf("ResolveRef() = %q, %q, want %q, %q", name, kind, tt.wantName, tt.wantKind)
+			}
+		})
+	}
+}
+
+// mockGraphQLClient は branch.GraphQLClient のモックです
+// 呼び出しごとに Responses を順に返します
+type mockGraphQLClient struct {
+	Responses []string
+	Cursors   []any
+	Variables []map[string]any
+	Error     error
+}
+
+func (m *mockGraphQLClient) Do(query string, variables map[string]any, response any) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	m.Cursors = append(m.Cursors, variables["cursor"])
+	vars := map[string]any{}
+	for k, v := range variables {
+		vars[k] = v
+	}
+	m.Variables = append(m.Variables, vars)
+	return json.Unmarshal([]byte(m.Responses[len(m.Cursors)-1]), response)
+}
+
+func TestLoadBranchesDetails(t *testing.T) {
+	client := &mockGraphQLClient{
+		Responses: []string{
+			`{"repository": {
+				"defaultBranchRef": {"name": "main"},
+				"refs": {
+					"pageInfo": {"hasNextPage": true, "endCursor": "c1"},
+					"nodes": [
+						{"name": "develop", "branchProtectionRule": null,
+						 "target": {"committedDate": "2026-01-02T03:04:05Z", "author": {"name": "Alice", "user": {"login": "alice"}}}},
+						{"name": "main", "branchProtectionRule": {"id": "r1"},
+						 "target": {"committedDate": "2026-01-01T00:00:00Z", "author": {"name": "Bob", "user": null}}}
+					]
+				}
+			}}`,
+			`{"repository": {
+				"defaultBranchRef": {"name": "main"},
+				"refs": {
+					"pageInfo": {"hasNextPage": false, "endCursor": "c2"},
+					"nodes": [
+						{"name": "feature", "branchProtectionRule": null,
+						 "target": {"committedDate": "2026-01-03T00:00:00Z", "author": {"name": "Carol", "user": {"login": "carol"}}}}
+					]
+				}
+			}}`,
+		},
+	}
+
+	got, searcher, err := LoadBranches(client, "user", "repo", SearchThreshold)
+	if err != nil {
+		t.Fatalf("LoadBranches() unexpected error: %v", err)
+	}
+	if searcher != nil {
+		t.Errorf("LoadBranches() returned a searcher for a small repository")
+	}
+
+	want := []Branch{
+		{Name: "develop", CommittedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Author: "alice"},
+		{Name: "main", Protected: true, CommittedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Author: "Bob", Default: true},
+		{Name: "feature", CommittedAt: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC), Author: "carol"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadBranches() = %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(client.Cursors, []any{nil, "c1"}) {
+		t.Errorf("LoadBranches() cursors = %v, want [<nil> c1]", client.Cursors)
+	}
+
+	SortByRecent(got)
+	var names []string
+	for _, b := range got {
+		names = append(names, b.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"feature", "develop", "main"}) {
+		t.Errorf("SortByRecent() = %v, want [feature develop main]", names)
+	}
+}
+
+func TestLoadBranchesError(t *testing.T) {
+	client := &mockGraphQLClient{Error: fmt.Errorf("graphql error")}
+
+	_, _, err := LoadBranches(client, "user", "repo", SearchThreshold)
+	if err == nil || err.Error() != "failed to fetch branches: graphql error" {
+		t.Errorf("LoadBranches() error = %v, want failed to fetch branches: graphql error", err)
+	}
+}
@@ -0,0 +1,92 @@
+package branch
+
+import (
+	"strings"
+	"sync"
+)
+
+// SearchThreshold はブランチ数がこれを超えるとサーバー側検索に切り替える閾値です
+const SearchThreshold = 1000
+
+// searchLimit は1回の検索で取得するブランチの件数です
+const searchLimit = 50
+
+// recentOrder は最新コミットが新しい順に並べる refs の orderBy です
+var recentOrder = map[string]any{"field": "TAG_COMMIT_DATE", "direction": "DESC"}
+
+// LoadBranches はブランチ一覧を取得します
+// ブランチ数が threshold 以下なら全件を取得し、Searcher は nil を返します
+// threshold を超える場合は最近更新されたブランチだけを取得し、以降の絞り込みに使う Searcher を返します
+func LoadBranches(client GraphQLClient, owner, repo string, threshold int) ([]Branch, *Searcher, error) {
+	variables := branchVariables(owner, repo)
+	first, err := fetchBranchPage(client, variables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if first.TotalCount <= threshold {
+		branches, err := fetchRemainingPages(client, variables, first)
+		if err != nil {
+			return nil, nil, err
+		}
+		return branches, nil, nil
+	}
+
+	searcher := NewSearcher(client, owner, repo)
+	branches, err := searcher.Search("")
+	if err != nil {
+		return nil, nil, err
+	}
+	return branches, searcher, nil
+}
+
+// Searcher は GraphQL の refs(query:) を使ってブランチ名でサーバー側検索を行います
+// 同じクエリの結果はキャッシュされ、複数の goroutine から安全に呼び出せます
+type Searcher struct {
+	client GraphQLClient
+	owner  string
+	repo   string
+
+	mu    sync.Mutex
+	cache map[string][]Branch
+}
+
+// NewSearcher は指定されたリポジトリのブランチを検索する Searcher を作成します
+func NewSearcher(client GraphQLClient, owner, repo string) *Searcher {
+	return &Searcher{
+		client: client,
+		owner:  owner,
+		repo:   repo,
+		cache:  map[string][]Branch{},
+	}
+}
+
+// Search は名前に query を含むブランチを最近更新された順に取得します
+// query が空の場合は最近更新されたブランチを返します
+func (s *Searcher) Search(query string) ([]Branch, error) {
+	query = strings.TrimSpace(query)
+
+	s.mu.Lock()
+	cached, ok := s.cache[query]
+	s.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	variables := branchVariables(s.owner, s.repo)
+	variables["first"] = searchLimit
+	variables["orderBy"] = recentOrder
+	if query != "" {
+		variables["query"] = query
+	}
+
+	page, err := fetchBranchPage(s.client, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[query] = page.Branches
+	s.mu.Unlock()
+	return page.Branches, nil
+}
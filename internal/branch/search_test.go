@@ -0,0 +1,142 @@
+package branch
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// branchPageJSON はテスト用の GraphQL レスポンスを組み立てます
+func branchPageJSON(total int, hasNext bool, cursor string, names ...string) string {
+	nodes := ""
+	for i, name := range names {
+		if i > 0 {
+			nodes += ","
+		}
+		nodes += fmt.Sprintf(`{"name": %q, "target": {"committedDate": "2026-01-01T00:00:00Z", "author": {"name": "Alice"}}}`, name)
+	}
+	return fmt.Sprintf(`{"repository": {
+		"defaultBranchRef": {"name": "main"},
+		"refs": {
+			"totalCount": %d,
+			"pageInfo": {"hasNextPage": %t, "endCursor": %q},
+			"nodes": [%s]
+		}
+	}}`, total, hasNext, cursor, nodes)
+}
+
+func branchNames(branches []Branch) []string {
+	names := []string{}
+	for _, b := range branches {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+func TestLoadBranches(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []string
+		threshold    int
+		wantNames    []string
+		wantSearcher bool
+		wantCalls    int
+	}{
+		{
+			name: "below threshold fetches every page",
+			responses: []string{
+				branchPageJSON(3, true, "c1", "develop", "feature"),
+				branchPageJSON(3, false, "c2", "main"),
+			},
+			threshold: 10,
+			wantNames: []string{"develop", "feature", "main"},
+			wantCalls: 2,
+		},
+		{
+			name: "above threshold switches to search",
+			responses: []string{
+				branchPageJSON(5000, true, "c1", "a", "b"),
+				branchPageJSON(5000, true, "s1", "recent", "main"),
+			},
+			threshold:    10,
+			wantNames:    []string{"recent", "main"},
+			wantSearcher: true,
+			wantCalls:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockGraphQLClient{Responses: tt.responses}
+
+			got, searcher, err := LoadBranches(client, "user", "repo", tt.threshold)
+			if err != nil {
+				t.Fatalf("LoadBranches() unexpected error: %v", err)
+			}
+			if names := branchNames(got); !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("LoadBranches() = %v, want %v", names, tt.wantNames)
+			}
+			if (searcher != nil) != tt.wantSearcher {
+				t.Errorf("LoadBranches() searcher = %v, want searcher %v", searcher, tt.wantSearcher)
+			}
+			if len(client.Variables) != tt.wantCalls {
+				t.Fatalf("LoadBranches() made %d requests, want %d", len(client.Variables), tt.wantCalls)
+			}
+			if tt.wantSearcher {
+				last := client.Variables[len(client.Variables)-1]
+				if last["first"] != searchLimit || !reflect.DeepEqual(last["orderBy"], recentOrder) {
+					t.Errorf("LoadBranches() search variables = %v", last)
+				}
+			}
+		})
+	}
+}
+
+func TestSearcherSearch(t *testing.T) {
+	client := &mockGraphQLClient{
+		Responses: []string{
+			branchPageJSON(2, false, "", "feature/login", "feature/logout"),
+			branchPageJSON(1, false, "", "fix/login"),
+		},
+	}
+	searcher := NewSearcher(client, "user", "repo")
+
+	got, err := searcher.Search(" feature ")
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	}
+	if names := branchNames(got); !reflect.DeepEqual(names, []string{"feature/login", "feature/logout"}) {
+		t.Errorf("Search() = %v, want [feature/login feature/logout]", names)
+	}
+	if q := client.Variables[0]["query"]; q != "feature" {
+		t.Errorf("Search() query variable = %v, want feature", q)
+	}
+
+	// 同じクエリはキャッシュから返す
+	if _, err := searcher.Search("feature"); err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	}
+	if len(client.Variables) != 1 {
+		t.Errorf("Search() made %d requests, want 1 (cached)", len(client.Variables))
+	}
+
+	got, err = searcher.Search("fix")
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	}
+	if names := branchNames(got); !reflect.DeepEqual(names, []string{"fix/login"}) {
+		t.Errorf("Search() = %v, want [fix/login]", names)
+	}
+}
+
+func TestSearcherSearchError(t *testing.T) {
+	client := &mockGraphQLClient{Error: fmt.Errorf("graphql error")}
+	searcher := NewSearcher(client, "user", "repo")
+
+	if _, err := searcher.Search("feature"); err == nil {
+		t.Fatal("Search() expected error, got nil")
+	}
+	if len(searcher.cache) != 0 {
+		t.Errorf("Search() cached a failed result: %v", searcher.cache)
+	}
+}
@@ -0,0 +1,107 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// RESTClient はAPIリクエストを行うためのインターフェース
+type RESTClient interface {
+	Get(path string, response any) error
+}
+
+// Environment はリポジトリのデプロイ環境を表します
+type Environment struct {
+	Name            string           `json:"name"`
+	ProtectionRules []ProtectionRule `json:"protection_rules"`
+	BranchPolicy    *BranchPolicy    `json:"deployment_branch_policy"`
+}
+
+// ProtectionRule は環境の保護ルールを表します
+type ProtectionRule struct {
+	Type      string `json:"type"`       // required_reviewers, wait_timer, branch_policy
+	WaitTimer int    `json:"wait_timer"` // 待機時間 (分)
+}
+
+// BranchPolicy はデプロイできるブランチの制限を表します
+type BranchPolicy struct {
+	ProtectedBranches    bool `json:"protected_branches"`
+	CustomBranchPolicies bool `json:"custom_branch_policies"`
+}
+
+// environmentsResponse は環境一覧 API のレスポンスです
+type environmentsResponse struct {
+	TotalCount   int           `json:"total_count"`
+	Environments []Environment `json:"environments"`
+}
+
+// perPage は1回のリクエストで取得する件数 (API の上限)
+const perPage = 100
+
+// Fetch は指定されたリポジトリの環境一覧をすべてのページから取得します
+// 環境を利用できないリポジトリ (404) では空の一覧を返します
+func Fetch(client RESTClient, owner, repo string) ([]Environment, error) {
+	all := []Environment{}
+
+	for page := 1; ; page++ {
+		var resp environmentsResponse
+		path := fmt.Sprintf("repos/%s/%s/environments?per_page=%d&page=%d", owner, repo, perPage, page)
+		if err := client.Get(path, &resp); err != nil {
+			var httpErr *api.HTTPError
+			if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+				return all, nil
+			}
+			return nil, fmt.Errorf("failed to fetch environments: %w", err)
+		}
+
+		all = append(all, resp.Environments...)
+		if len(resp.Environments) < perPage || len(all) >= resp.TotalCount {
+			return all, nil
+		}
+	}
+}
+
+// Names は環境名の一覧を返します
+func Names(envs []Environment) []string {
+	names := make([]string, len(envs))
+	for i, env := range envs {
+		names[i] = env.Name
+	}
+	return names
+}
+
+// protection は環境の保護内容を表示用の文字列の一覧で返します
+func (e Environment) protection() []string {
+	var rules []string
+	for _, rule := range e.ProtectionRules {
+		switch rule.Type {
+		case "required_reviewers":
+			rules = append(rules, "required reviewers")
+		case "wait_timer":
+			rules = append(rules, fmt.Sprintf("wait timer %dm", rule.WaitTimer))
+		}
+	}
+
+	if e.BranchPolicy != nil {
+		switch {
+		case e.BranchPolicy.ProtectedBranches:
+			rules = append(rules, "protected branches only")
+		case e.BranchPolicy.CustomBranchPolicies:
+			rules = append(rules, "selected branches only")
+		}
+	}
+	return rules
+}
+
+// Summary は環境の保護状態を 1 行で返します
+func (e Environment) Summary() string {
+	rules := e.protection()
+	if len(rules) == 0 {
+		return "unprotected"
+	}
+	return "protected: " + strings.Join(rules, ", ")
+}
@@ -0,0 +1,171 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// pagedRESTClient はページ番号ごとに異なるレスポンスを返すモックです
+type pagedRESTClient struct {
+	Pages      [][]Environment
+	TotalCount int
+	Error      error
+	Paths      []string
+}
+
+func (m *pagedRESTClient) Get(path string, response any) error {
+	m.Paths = append(m.Paths, path)
+	if m.Error != nil {
+		return m.Error
+	}
+
+	resp := environmentsResponse{TotalCount: m.TotalCount}
+	if idx := len(m.Paths) - 1; idx < len(m.Pages) {
+		resp.Environments = m.Pages[idx]
+	}
+	b, _ := json.Marshal(resp)
+	return json.Unmarshal(b, response)
+}
+
+func page(prefix string, n int) []Environment {
+	envs := make([]Environment, n)
+	for i := range envs {
+		envs[i] = Environment{Name: fmt.Sprintf("%s-%d", prefix, i)}
+	}
+	return envs
+}
+
+func TestFetch(t *testing.T) {
+	tests := []struct {
+		name          string
+		pages         [][]Environment
+		totalCount    int
+		clientErr     error
+		wantCount     int
+		wantRequests  int
+		wantErrString string
+	}{
+		{name: "single page", pages: [][]Environment{page("a", 2)}, totalCount: 2, wantCount: 2, wantRequests: 1},
+		{name: "multiple pages", pages: [][]Environment{page("a", 100), page("b", 5)}, totalCount: 105, wantCount: 105, wantRequests: 2},
+		{name: "exactly one full page", pages: [][]Environment{page("a", 100)}, totalCount: 100, wantCount: 100, wantRequests: 1},
+		{name: "no environments", totalCount: 0, wantCount: 0, wantRequests: 1},
+		{name: "not available", clientErr: &api.HTTPError{StatusCode: 404, Message: "Not Found"}, wantCount: 0, wantRequests: 1},
+		{name: "api error", clientErr: fmt.Errorf("network error"), wantRequests: 1, wantErrString: "failed to fetch environments: network error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &pagedRESTClient{Pages: tt.pages, TotalCount: tt.totalCount, Error: tt.clientErr}
+
+			got, err := Fetch(client, "user", "repo")
+
+			if tt.wantErrString != "" {
+				if err == nil || err.Error() != tt.wantErrString {
+					t.Fatalf("Fetch() error = %v, want %q", err, tt.wantErrString)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Fetch() unexpected error: %v", err)
+				}
+				if len(got) != tt.wantCount {
+					t.Errorf("Fetch() returned %d environments, want %d", len(got), tt.wantCount)
+				}
+			}
+			if len(client.Paths) != tt.wantRequests {
+				t.Errorf("Fetch() made %d requests, want %d", len(client.Paths), tt.wantRequests)
+			}
+			if client.Paths[0] != "repos/user/repo/environments?per_page=100&page=1" {
+				t.Errorf("Fetch() requested %v", client.Paths)
+			}
+		})
+	}
+}
+
+func TestFetchParsesProtection(t *testing.T) {
+	client := &apiRESTClient{body: `{
+		"total_count": 2,
+		"environments": [
+			{"name": "production", "protection_rules": [{"type": "required_reviewers", "reviewers": []}, {"type": "wait_timer", "wait_timer": 30}], "deployment_branch_policy": {"protected_branches": true, "custom_branch_policies": false}},
+			{"name": "staging", "protection_rules": [], "deployment_branch_policy": null}
+		]
+	}`}
+
+	got, err := Fetch(client, "user", "repo")
+	if err != nil {
+		t.Fatalf("Fetch() unexpected error: %v", err)
+	}
+
+	want := []Environment{
+		{
+			Name:            "production",
+			ProtectionRules: []ProtectionRule{{Type: "required_reviewers"}, {Type: "wait_timer", WaitTimer: 30}},
+			BranchPolicy:    &BranchPolicy{ProtectedBranches: true},
+		},
+		{Name: "staging", ProtectionRules: []ProtectionRule{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fetch() = %+v, want %+v", got, want)
+	}
+}
+
+// apiRESTClient は固定の JSON を返すモックです
+type apiRESTClient struct {
+	body string
+}
+
+func (m *apiRESTClient) Get(path string, response any) error {
+	return json.Unmarshal([]byte(m.body), response)
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         Environment
+		wantSummary string
+	}{
+		{name: "unprotected", env: Environment{Name: "dev"}, wantSummary: "unprotected"},
+		{
+			name:        "reviewers",
+			env:         Environment{ProtectionRules: []ProtectionRule{{Type: "required_reviewers"}}},
+			wantSummary: "protected: required reviewers",
+		},
+		{
+			name: "all rules",
+			env: Environment{
+				ProtectionRules: []ProtectionRule{{Type: "required_reviewers"}, {Type: "wait_timer", WaitTimer: 5}, {Type: "branch_policy"}},
+				BranchPolicy:    &BranchPolicy{CustomBranchPolicies: true},
+			},
+			wantSummary: "protected: required reviewers, wait timer 5m, selected branches only",
+		},
+		{
+			name:        "protected branches",
+			env:         Environment{BranchPolicy: &BranchPolicy{ProtectedBranches: true}},
+			wantSummary: "protected: protected branches only",
+		},
+		{
+			name:        "branch policy without restriction",
+			env:         Environment{BranchPolicy: &BranchPolicy{}},
+			wantSummary: "unprotected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.env.Summary(); got != tt.wantSummary {
+				t.Errorf("Summary() = %q, want %q", got, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	got := Names([]Environment{{Name: "production"}, {Name: "staging"}})
+	want := []string{"production", "staging"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+}
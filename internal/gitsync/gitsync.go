@@ -0,0 +1,136 @@
+package gitsync
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Status はローカルブランチとリモートブランチの差分を表します
+type Status struct {
+	Branch string // ブランチ名
+	Remote string // 比較したリモート名 (例: origin)
+	Pushed bool   // リモートに同名のブランチがあるか
+	Ahead  int    // ローカルにだけあるコミット数 (未 push)
+	Behind int    // リモートにだけあるコミット数
+}
+
+// InSync はローカルとリモートが同じコミットを指しているかを返します
+func (s Status) InSync() bool {
+	return s.Pushed && s.Ahead == 0 && s.Behind == 0
+}
+
+// CanPush は push するとリモートが最新になる状態かを返します
+func (s Status) CanPush() bool {
+	return !s.Pushed || (s.Ahead > 0 && s.Behind == 0)
+}
+
+// Warning は確認画面に表示する警告を返します。同期している場合は空文字を返します
+func (s Status) Warning() string {
+	remoteRef := s.Remote + "/" + s.Branch
+	switch {
+	case s.InSync():
+		return ""
+	case !s.Pushed:
+		return fmt.Sprintf("Branch %q has not been pushed to %s, so GitHub cannot run it", s.Branch, s.Remote)
+	case s.Ahead > 0 && s.Behind > 0:
+		return fmt.Sprintf("Branch %q has diverged from %s (%s ahead, %s behind); the run will use the remote commit", s.Branch, remoteRef, plural(s.Ahead), plural(s.Behind))
+	case s.Ahead > 0:
+		return fmt.Sprintf("Branch %q has %s not pushed to %s; the run will not include them", s.Branch, plural(s.Ahead), remoteRef)
+	default:
+		return fmt.Sprintf("Branch %q is %s behind %s; the run will use the remote commit", s.Branch, plural(s.Behind), remoteRef)
+	}
+}
+
+// plural はコミット数を単数・複数形に合わせて返します
+func plural(n int) string {
+	if n == 1 {
+		return "1 commit"
+	}
+	return fmt.Sprintf("%d commits", n)
+}
+
+// runGit は dir で git コマンドを実行し、標準出力を返します (テストで差し替えます)
+var runGit = func(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// Compare はローカルの branch と、owner/repo を指すリモートの同名ブランチを比較します
+// リモートの状態は最後に fetch した時点のリモート追跡ブランチで判断します
+// ローカルに branch がない場合や git リポジトリでない場合は nil を返します
+func Compare(dir, branch, owner, repo string) (*Status, error) {
+	localRef := "refs/heads/" + branch
+	if _, err := runGit(dir, "rev-parse", "--verify", "--quiet", localRef); err != nil {
+		return nil, nil
+	}
+
+	remotes, err := runGit(dir, "remote", "-v")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list git remotes: %w", err)
+	}
+	remote := FindRemote(remotes, owner, repo)
+	if remote == "" {
+		return nil, nil
+	}
+
+	status := &Status{Branch: branch, Remote: remote}
+	remoteRef := "refs/remotes/" + remote + "/" + branch
+	if _, err := runGit(dir, "rev-parse", "--verify", "--quiet", remoteRef); err != nil {
+		return status, nil
+	}
+	status.Pushed = true
+
+	out, err := runGit(dir, "rev-list", "--left-right", "--count", localRef+"..."+remoteRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s with %s/%s: %w", branch, remote, branch, err)
+	}
+	status.Ahead, status.Behind, err = ParseAheadBehind(out)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// PushCommand は branch を status のリモートへ push するコマンドを返します
+func PushCommand(dir string, status Status) *exec.Cmd {
+	cmd := exec.Command("git", "push", status.Remote, status.Branch)
+	cmd.Dir = dir
+	return cmd
+}
+
+// ParseAheadBehind は `git rev-list --left-right --count` の出力 ("<ahead>\t<behind>") を解析します
+func ParseAheadBehind(out string) (ahead, behind int, err error) {
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", strings.TrimSpace(out))
+	}
+	if ahead, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", strings.TrimSpace(out))
+	}
+	if behind, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", strings.TrimSpace(out))
+	}
+	return ahead, behind, nil
+}
+
+// FindRemote は `git remote -v` の出力から owner/repo を指すリモート名を返します
+// 一致するリモートがない場合は空文字を返します
+func FindRemote(out, owner, repo string) string {
+	target := strings.ToLower(owner + "/" + repo)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		path := strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(fields[1], "/"), ".git"))
+		if strings.HasSuffix(path, "/"+target) || strings.HasSuffix(path, ":"+target) {
+			return fields[0]
+		}
+	}
+	return ""
+}
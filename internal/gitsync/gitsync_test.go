@@ -0,0 +1,144 @@
+package gitsync
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAheadBehind(t *testing.T) {
+	tests := []struct {
+		name       string
+		out        string
+		wantAhead  int
+		wantBehind int
+		wantErr    bool
+	}{
+		{name: "in sync", out: "0\t0\n", wantAhead: 0, wantBehind: 0},
+		{name: "ahead and behind", out: "3\t1\n", wantAhead: 3, wantBehind: 1},
+		{name: "empty", out: "", wantErr: true},
+		{name: "not a number", out: "x\t1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ahead, behind, err := ParseAheadBehind(tt.out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAheadBehind() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ahead != tt.wantAhead || behind != tt.wantBehind {
+				t.Errorf("ParseAheadBehind() = %d, %d, want %d, %d", ahead, behind, tt.wantAhead, tt.wantBehind)
+			}
+		})
+	}
+}
+
+func TestFindRemote(t *testing.T) {
+	out := `fork	git@github.com:alice/gh-dispatch.git (fetch)
+fork	git@github.com:alice/gh-dispatch.git (push)
+origin	https://github.com/Yanskun/gh-dispatch.git (fetch)
+origin	https://github.com/Yanskun/gh-dispatch.git (push)
+work	github-work:yanskun/other (fetch)
+`
+	tests := []struct {
+		name  string
+		owner string
+		repo  string
+		want  string
+	}{
+		{name: "https remote", owner: "yanskun", repo: "gh-dispatch", want: "origin"},
+		{name: "ssh remote", owner: "alice", repo: "gh-dispatch", want: "fork"},
+		{name: "ssh alias", owner: "yanskun", repo: "other", want: "work"},
+		{name: "no match", owner: "bob", repo: "gh-dispatch", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindRemote(out, tt.owner, tt.repo); got != tt.want {
+				t.Errorf("FindRemote() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	remotes := "origin\thttps://github.com/user/repo.git (fetch)\n"
+	tests := []struct {
+		name    string
+		outputs map[string]string // git の引数 → 出力 (ないものは失敗扱い)
+		want    *Status
+	}{
+		{
+			name:    "no local branch",
+			outputs: map[string]string{},
+			want:    nil,
+		},
+		{
+			name: "not pushed",
+			outputs: map[string]string{
+				"rev-parse --verify --quiet refs/heads/feature": "abc\n",
+				"remote -v": remotes,
+			},
+			want: &Status{Branch: "feature", Remote: "origin"},
+		},
+		{
+			name: "ahead",
+			outputs: map[string]string{
+				"rev-parse --verify --quiet refs/heads/feature": "abc\n",
+				"remote -v": remotes,
+				"rev-parse --verify --quiet refs/remotes/origin/feature":                         "def\n",
+				"rev-list --left-right --count refs/heads/feature...refs/remotes/origin/feature": "2\t0\n",
+			},
+			want: &Status{Branch: "feature", Remote: "origin", Pushed: true, Ahead: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := runGit
+			defer func() { runGit = orig }()
+			runGit = func(dir string, args ...string) (string, error) {
+				out, ok := tt.outputs[strings.Join(args, " ")]
+				if !ok {
+					return "", fmt.Errorf("exit status 1")
+				}
+				return out, nil
+			}
+
+			got, err := Compare("", "feature", "user", "repo")
+			if err != nil {
+				t.Fatalf("Compare() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusWarning(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      Status
+		want        string
+		wantCanPush bool
+	}{
+		{name: "in sync", status: Status{Branch: "main", Remote: "origin", Pushed: true}, want: ""},
+		{name: "not pushed", status: Status{Branch: "feature", Remote: "origin"}, want: `Branch "feature" has not been pushed to origin, so GitHub cannot run it`, wantCanPush: true},
+		{name: "ahead", status: Status{Branch: "main", Remote: "origin", Pushed: true, Ahead: 1}, want: `Branch "main" has 1 commit not pushed to origin/main; the run will not include them`, wantCanPush: true},
+		{name: "behind", status: Status{Branch: "main", Remote: "origin", Pushed: true, Behind: 2}, want: `Branch "main" is 2 commits behind origin/main; the run will use the remote commit`},
+		{name: "diverged", status: Status{Branch: "main", Remote: "origin", Pushed: true, Ahead: 2, Behind: 1}, want: `Branch "main" has diverged from origin/main (2 commits ahead, 1 commit behind); the run will use the remote commit`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.status.Warning(); got != tt.want {
+				t.Errorf("Warning() = %q, want %q", got, tt.want)
+			}
+			if got := tt.status.CanPush(); got != tt.wantCanPush {
+				t.Errorf("CanPush() = %v, want %v", got, tt.wantCanPush)
+			}
+		})
+	}
+}
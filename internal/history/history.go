@@ -0,0 +1,99 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+)
+
+// Entry は成功したワークフロー実行1回分の記録を表します
+type Entry struct {
+	Repo         string            `json:"repo"`     // [HOST/]OWNER/REPO (github.com の場合はホストを省略)
+	Workflow     string            `json:"workflow"` // ワークフローのファイル名
+	WorkflowName string            `json:"workflow_name,omitempty"`
+	Ref          string            `json:"ref"`
+	Inputs       map[string]string `json:"inputs,omitempty"`
+	RunURL       string            `json:"run_url,omitempty"` // 作成された実行の URL (特定できた場合)
+	Time         time.Time         `json:"time"`
+}
+
+// DefaultPath は履歴ファイルのパスを返します
+func DefaultPath() string {
+	return filepath.Join(config.StateDir(), "gh-dispatch", "history.jsonl")
+}
+
+// Append は履歴ファイルの末尾にエントリを追記します
+func Append(path string, e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Load は履歴ファイルを読み込み、新しい順に返します
+// ファイルが存在しない場合は空の一覧を返します
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// 壊れた行は無視する
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	// 新しい順に並べ替える
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// ForRepo は指定リポジトリ ([HOST/]OWNER/REPO) のエントリのみを返します
+func ForRepo(entries []Entry, repo string) []Entry {
+	var matched []Entry
+	for _, e := range entries {
+		if e.Repo == repo {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
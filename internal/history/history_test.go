@@ -0,0 +1,66 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gh-dispatch", "history.jsonl")
+
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []Entry{
+		{Repo: "user/repo", Workflow: "deploy.yml", WorkflowName: "Deploy", Ref: "main", Inputs: map[string]string{"environment": "staging"}, Time: base},
+		{Repo: "user/other", Workflow: "ci.yml", Ref: "develop", Time: base.Add(time.Minute)},
+		{Repo: "user/repo", Workflow: "deploy.yml", WorkflowName: "Deploy", Ref: "v1.0.0", Inputs: map[string]string{"environment": "production"}, Time: base.Add(2 * time.Minute)},
+	}
+	for _, e := range entries {
+		if err := Append(path, e); err != nil {
+			t.Fatalf("Append() unexpected error: %v", err)
+		}
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	want := []Entry{entries[2], entries[1], entries[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+
+	if got := ForRepo(got, "user/repo"); !reflect.DeepEqual(got, []Entry{entries[2], entries[0]}) {
+		t.Errorf("ForRepo() = %+v, want entries of user/repo", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	missing, err := Load(filepath.Join(dir, "missing.jsonl"))
+	if err != nil || missing != nil {
+		t.Errorf("Load() for missing file = %v, %v, want nil, nil", missing, err)
+	}
+
+	path := filepath.Join(dir, "history.jsonl")
+	content := `{"repo":"user/repo","workflow":"deploy.yml","ref":"main","time":"2026-01-02T03:04:05Z"}
+not json
+
+{"repo":"user/repo","workflow":"ci.yml","ref":"main","time":"2026-01-02T03:05:05Z"}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].Workflow != "ci.yml" || got[1].Workflow != "deploy.yml" {
+		t.Errorf("Load() = %+v, want ci.yml and deploy.yml skipping broken lines", got)
+	}
+}
@@ -0,0 +1,111 @@
+package preset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Preset はワークフローごとに名前を付けて保存した input 値の組み合わせを表します
+type Preset struct {
+	Name     string            `yaml:"name"`
+	Workflow string            `yaml:"workflow"`       // ワークフローのファイル名 (例: deploy.yml)
+	Repo     string            `yaml:"repo,omitempty"` // [HOST/]OWNER/REPO (ユーザー単位のファイルでのみ使用、github.com の場合はホストを省略)
+	Inputs   map[string]string `yaml:"inputs"`
+}
+
+// presetsYAML はプリセットファイルのパース用構造体
+type presetsYAML struct {
+	Presets []Preset `yaml:"presets"`
+}
+
+// RepoPath はリポジトリ単位のプリセットファイルのパスを返します
+func RepoPath(rootPath string) string {
+	return filepath.Join(rootPath, ".github", "dispatch-presets.yml")
+}
+
+// UserPath はユーザー単位のプリセットファイルのパスを返します
+func UserPath() string {
+	return filepath.Join(config.ConfigDir(), "gh-dispatch", "presets.yml")
+}
+
+// Load はプリセットファイルを読み込みます。ファイルが存在しない場合は空の一覧を返します
+func Load(path string) ([]Preset, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read presets: %w", err)
+	}
+
+	var f presetsYAML
+	if err := yaml.Unmarshal(content, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse presets in %s: %w", path, err)
+	}
+
+	return f.Presets, nil
+}
+
+// Save はプリセットをファイルに保存します
+// 同じリポジトリ・ワークフロー・名前のプリセットがある場合は上書きします
+func Save(path string, p Preset) error {
+	presets, err := Load(path)
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, existing := range presets {
+		if existing.Name == p.Name && existing.Workflow == p.Workflow && existing.Repo == p.Repo {
+			presets[i] = p
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		presets = append(presets, p)
+	}
+
+	content, err := yaml.Marshal(presetsYAML{Presets: presets})
+	if err != nil {
+		return fmt.Errorf("failed to marshal presets: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create presets directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write presets: %w", err)
+	}
+	return nil
+}
+
+// ForWorkflow はリポジトリ ([HOST/]OWNER/REPO) とワークフローのファイル名に一致するプリセットを返します
+// repo が指定されていないプリセットはどのリポジトリにも一致します
+func ForWorkflow(presets []Preset, repo, workflowFile string) []Preset {
+	var matched []Preset
+	for _, p := range presets {
+		if p.Workflow != workflowFile {
+			continue
+		}
+		if p.Repo != "" && p.Repo != repo {
+			continue
+		}
+		matched = append(matched, p)
+	}
+	return matched
+}
+
+// Find は名前でプリセットを検索します
+func Find(presets []Preset, name string) (Preset, error) {
+	for _, p := range presets {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Preset{}, fmt.Errorf("preset %q not found", name)
+}
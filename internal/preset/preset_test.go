@@ -0,0 +1,101 @@
+package preset
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dispatch-presets.yml")
+	content := `
+presets:
+  - name: staging hotfix
+    workflow: deploy.yml
+    inputs:
+      environment: staging
+      dry_run: "false"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	want := []Preset{
+		{
+			Name:     "staging hotfix",
+			Workflow: "deploy.yml",
+			Inputs:   map[string]string{"environment": "staging", "dry_run": "false"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+
+	missing, err := Load(filepath.Join(dir, "missing.yml"))
+	if err != nil || missing != nil {
+		t.Errorf("Load() for missing file = %v, %v, want nil, nil", missing, err)
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gh-dispatch", "presets.yml")
+
+	presets := []Preset{
+		{Name: "staging", Workflow: "deploy.yml", Repo: "user/repo", Inputs: map[string]string{"environment": "staging"}},
+		{Name: "production", Workflow: "deploy.yml", Repo: "user/repo", Inputs: map[string]string{"environment": "production"}},
+		{Name: "staging", Workflow: "deploy.yml", Repo: "user/repo", Inputs: map[string]string{"environment": "staging", "version": "v2"}},
+	}
+	for _, p := range presets {
+		if err := Save(path, p); err != nil {
+			t.Fatalf("Save() unexpected error: %v", err)
+		}
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	want := []Preset{
+		{Name: "staging", Workflow: "deploy.yml", Repo: "user/repo", Inputs: map[string]string{"environment": "staging", "version": "v2"}},
+		{Name: "production", Workflow: "deploy.yml", Repo: "user/repo", Inputs: map[string]string{"environment": "production"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() after Save() = %+v, want %+v", got, want)
+	}
+}
+
+func TestForWorkflow(t *testing.T) {
+	presets := []Preset{
+		{Name: "shared", Workflow: "deploy.yml"},
+		{Name: "mine", Workflow: "deploy.yml", Repo: "user/repo"},
+		{Name: "other repo", Workflow: "deploy.yml", Repo: "user/other"},
+		{Name: "other host", Workflow: "deploy.yml", Repo: "ghes.example.com/user/repo"},
+		{Name: "other workflow", Workflow: "release.yml"},
+	}
+
+	got := ForWorkflow(presets, "user/repo", "deploy.yml")
+
+	var names []string
+	for _, p := range got {
+		names = append(names, p.Name)
+	}
+	want := []string{"shared", "mine"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("ForWorkflow() = %v, want %v", names, want)
+	}
+
+	if _, err := Find(got, "mine"); err != nil {
+		t.Errorf("Find() unexpected error: %v", err)
+	}
+	if _, err := Find(got, "other repo"); err == nil || err.Error() != `preset "other repo" not found` {
+		t.Errorf("Find() error = %v, want preset not found", err)
+	}
+}
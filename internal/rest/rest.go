@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/auth"
+)
+
+// Client は go-gh の RESTClient の Request だけを http.Client で直接行うクライアントです
+// go-gh の Request はエラーレスポンスの本文を読み捨てて HTTPError にするため、
+// documentation_url などを読めるようエラーレスポンスもそのまま返します
+type Client struct {
+	*api.RESTClient
+	http *http.Client
+	host string
+}
+
+// New は opts のホストに向けた Client を作成します
+// 認証やヘッダーは go-gh の RESTClient と同じ http.Client を使います
+func New(opts api.ClientOptions) (*Client, error) {
+	rest, err := api.NewRESTClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := api.NewHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{RESTClient: rest, http: httpClient, host: opts.Host}, nil
+}
+
+// Request は method と path のリクエストを送信し、エラーレスポンスも含めてそのまま返します
+func (c *Client) Request(method string, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, restURL(c.host, path), body)
+	if err != nil {
+		return nil, err
+	}
+	return c.http.Do(req)
+}
+
+// restURL は go-gh の RESTClient (pkg/api の restURL と restPrefix) と同じ順序・規則で
+// REST API の URL を組み立てます
+func restURL(host, path string) string {
+	if strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://") {
+		return path
+	}
+	if strings.EqualFold(host, "garage.github.com") {
+		return fmt.Sprintf("https://%s/api/v3/%s", host, path)
+	}
+	host = auth.NormalizeHostname(host)
+	if auth.IsEnterprise(host) {
+		return fmt.Sprintf("https://%s/api/v3/%s", host, path)
+	}
+	if strings.EqualFold(host, "github.localhost") {
+		return fmt.Sprintf("http://api.%s/%s", host, path)
+	}
+	return fmt.Sprintf("https://api.%s/%s", host, path)
+}
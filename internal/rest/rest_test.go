@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+func TestRestURL(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		path string
+		want string
+	}{
+		{
+			name: "github.com",
+			host: "github.com",
+			path: "repos/user/repo",
+			want: "https://api.github.com/repos/user/repo",
+		},
+		{
+			name: "github.com subdomain is normalized",
+			host: "API.GitHub.com",
+			path: "user",
+			want: "https://api.github.com/user",
+		},
+		{
+			name: "GitHub Enterprise Server",
+			host: "ghes.example.com",
+			path: "repos/user/repo",
+			want: "https://ghes.example.com/api/v3/repos/user/repo",
+		},
+		{
+			name: "GitHub Enterprise Server host is lowercased before the enterprise check",
+			host: "GHES.Example.com",
+			path: "user",
+			want: "https://ghes.example.com/api/v3/user",
+		},
+		{
+			name: "GHE.com",
+			host: "acme.ghe.com",
+			path: "repos/user/repo",
+			want: "https://api.acme.ghe.com/repos/user/repo",
+		},
+		{
+			name: "GHE.com subdomain is normalized",
+			host: "api.acme.ghe.com",
+			path: "user",
+			want: "https://api.acme.ghe.com/user",
+		},
+		{
+			name: "garage",
+			host: "garage.github.com",
+			path: "repos/user/repo",
+			want: "https://garage.github.com/api/v3/repos/user/repo",
+		},
+		{
+			name: "localhost",
+			host: "github.localhost",
+			path: "user",
+			want: "http://api.github.localhost/user",
+		},
+		{
+			name: "absolute URL is used as is",
+			host: "ghes.example.com",
+			path: "https://api.github.com/repos/user/repo/branches?page=2",
+			want: "https://api.github.com/repos/user/repo/branches?page=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := restURL(tt.host, tt.path); got != tt.want {
+				t.Errorf("restURL(%q, %q) = %q, want %q", tt.host, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// roundTripFunc は関数を http.RoundTripper として使うための型です
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClientRequest(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		b, _ := io.ReadAll(req.Body)
+		gotBody = string(b)
+		return &http.Response{
+			StatusCode: http.StatusUnprocessableEntity,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"message":"Unexpected inputs provided","documentation_url":"https://docs.github.com"}`)),
+			Request:    req,
+		}, nil
+	})
+
+	client, err := New(api.ClientOptions{Host: "ghes.example.com", AuthToken: "secret", Transport: transport})
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+
+	resp, err := client.Request(http.MethodPost, "repos/user/repo/actions/workflows/deploy.yml/dispatches", strings.NewReader(`{"ref":"main"}`))
+	if err != nil {
+		t.Fatalf("Request() unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got.Method != http.MethodPost || got.URL.String() != "https://ghes.example.com/api/v3/repos/user/repo/actions/workflows/deploy.yml/dispatches" {
+		t.Errorf("request = %s %s", got.Method, got.URL)
+	}
+	if got.Header.Get("Authorization") != "token secret" {
+		t.Errorf("Authorization header = %q, want the token of the host", got.Header.Get("Authorization"))
+	}
+	if gotBody != `{"ref":"main"}` {
+		t.Errorf("request body = %q", gotBody)
+	}
+
+	// エラーレスポンスは HTTPError にせず本文ごと返す
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("StatusCode = %d, want %d", resp.StatusCode, http.StatusUnprocessableEntity)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"documentation_url":"https://docs.github.com"`) {
+		t.Errorf("response body = %q, want the error response body", body)
+	}
+}
@@ -0,0 +1,217 @@
+package retry
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// RESTClient は再試行の対象となる REST クライアントのインターフェース
+// workflow.RESTClient と branch.RESTClient の両方を満たします
+type RESTClient interface {
+	Request(method string, path string, body io.Reader) (*http.Response, error)
+	Get(path string, response any) error
+}
+
+const (
+	maxRetries = 3                // 最初のリクエストに加えて再試行する最大回数
+	baseDelay  = time.Second      // 指数バックオフの初回の待ち時間
+	maxWait    = 60 * time.Second // これより長く待つ必要がある場合は再試行せずにエラーを返す
+)
+
+// GraphQLClient は再試行の対象となる GraphQL クライアントのインターフェース
+// branch.GraphQLClient を満たします
+type GraphQLClient interface {
+	Do(query string, variables map[string]any, response any) error
+}
+
+// backoff は再試行までの待ち方を表します (テストでは sleep と now を差し替えます)
+type backoff struct {
+	sleep func(time.Duration)
+	now   func() time.Time
+}
+
+// Client は一時的なエラーで失敗したリクエストを再試行する RESTClient のラッパーです
+//
+// GET は 5xx とレートリミットで再試行します。GET 以外 (dispatch の POST など) は
+// GitHub がリクエストを処理せずに拒否したことが明らかなレートリミットの場合のみ再試行し、
+// 5xx やネットワークエラーでは二重に実行されないよう再試行しません
+type Client struct {
+	backoff
+	client RESTClient
+}
+
+// New は client の呼び出しを再試行する Client を作成します
+func New(client RESTClient) *Client {
+	return &Client{backoff: backoff{sleep: time.Sleep, now: time.Now}, client: client}
+}
+
+// GraphQL は一時的なエラーで失敗したクエリを再試行する GraphQLClient のラッパーです
+// クエリは読み取りのみのため、GET と同じく 5xx とレートリミットで再試行します
+type GraphQL struct {
+	backoff
+	client GraphQLClient
+}
+
+// NewGraphQL は client の呼び出しを再試行する GraphQL を作成します
+func NewGraphQL(client GraphQLClient) *GraphQL {
+	return &GraphQL{backoff: backoff{sleep: time.Sleep, now: time.Now}, client: client}
+}
+
+// failure は失敗したレスポンスの再試行の判断に使う情報です
+type failure struct {
+	status  int
+	headers http.Header
+	message string
+}
+
+// Request は method と path のリクエストを送信し、必要に応じて再試行します
+func (c *Client) Request(method string, path string, body io.Reader) (*http.Response, error) {
+	// 再試行で同じ本文を送り直せるよう読み込んでおく
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
+	idempotent := method == http.MethodGet || method == http.MethodHead
+
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(payload)
+		}
+
+		resp, err := c.client.Request(method, path, reader)
+		f, failed := responseFailure(resp, err)
+		if !failed {
+			return resp, err
+		}
+
+		wait, ok := c.retryDelay(f, idempotent, attempt)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		c.sleep(wait)
+	}
+}
+
+// Get は path を GET して response にデコードし、必要に応じて再試行します
+func (c *Client) Get(path string, response any) error {
+	return c.retryCall(func() error {
+		return c.client.Get(path, response)
+	})
+}
+
+// Do は GraphQL のクエリを実行して response にデコードし、必要に応じて再試行します
+func (g *GraphQL) Do(query string, variables map[string]any, response any) error {
+	return g.retryCall(func() error {
+		return g.client.Do(query, variables, response)
+	})
+}
+
+// retryCall は読み取りのみの呼び出し call を、HTTP のエラーで失敗した場合に再試行します
+func (b backoff) retryCall(call func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil {
+			return nil
+		}
+
+		f, failed := responseFailure(nil, err)
+		if !failed {
+			return err
+		}
+		wait, ok := b.retryDelay(f, true, attempt)
+		if !ok {
+			return err
+		}
+		b.sleep(wait)
+	}
+}
+
+// responseFailure はレスポンスまたはエラーから HTTP のエラー情報を取り出します
+// HTTP のエラーでない場合 (成功やネットワークエラー) は false を返します
+func responseFailure(resp *http.Response, err error) (failure, bool) {
+	if err != nil {
+		var httpErr *api.HTTPError
+		if !errors.As(err, &httpErr) {
+			return failure{}, false
+		}
+		return failure{status: httpErr.StatusCode, headers: httpErr.Headers, message: httpErr.Message}, true
+	}
+
+	if resp == nil || (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return failure{}, false
+	}
+
+	// 呼び出し元が本文を読めるよう、読み込んだ内容で差し替える
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return failure{status: resp.StatusCode, headers: resp.Header, message: string(body)}, true
+}
+
+// retryDelay は失敗したリクエストを再試行するまでの待ち時間を返します
+// 再試行しない場合は false を返します
+func (b backoff) retryDelay(f failure, idempotent bool, attempt int) (time.Duration, bool) {
+	if attempt >= maxRetries {
+		return 0, false
+	}
+
+	rateLimited := isRateLimited(f)
+	if !rateLimited && !(idempotent && f.status >= 500) {
+		return 0, false
+	}
+
+	wait := baseDelay << attempt
+	if d, ok := b.rateLimitWait(f.headers); ok {
+		wait = d
+	}
+	if wait > maxWait {
+		return 0, false
+	}
+	return wait, true
+}
+
+// isRateLimited はレートリミット (セカンダリーレートリミットを含む) による拒否かを返します
+func isRateLimited(f failure) bool {
+	if f.status == http.StatusTooManyRequests {
+		return true
+	}
+	if f.status != http.StatusForbidden {
+		return false
+	}
+	return f.headers.Get("Retry-After") != "" ||
+		f.headers.Get("X-RateLimit-Remaining") == "0" ||
+		strings.Contains(strings.ToLower(f.message), "rate limit")
+}
+
+// rateLimitWait は Retry-After または X-RateLimit-Reset から待ち時間を求めます
+func (b backoff) rateLimitWait(headers http.Header) (time.Duration, bool) {
+	if v := headers.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(t.Sub(b.now()), 0), true
+		}
+	}
+
+	if headers.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(headers.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(b.now()), 0), true
+		}
+	}
+	return 0, false
+}
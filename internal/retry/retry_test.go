@@ -0,0 +1,295 @@
+package retry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// scripted は fakeClient が返すレスポンス1回分です
+type scripted struct {
+	status  int
+	headers http.Header
+	body    string
+	err     error
+}
+
+// fakeClient は決められた順にレスポンスを返す RESTClient のモックです
+type fakeClient struct {
+	responses []scripted
+	bodies    []string // 受け取ったリクエストの本文
+}
+
+func (f *fakeClient) next() scripted {
+	r := f.responses[0]
+	f.responses = f.responses[1:]
+	return r
+}
+
+func (f *fakeClient) Request(method string, path string, body io.Reader) (*http.Response, error) {
+	if body != nil {
+		b, _ := io.ReadAll(body)
+		f.bodies = append(f.bodies, string(b))
+	} else {
+		f.bodies = append(f.bodies, "")
+	}
+
+	r := f.next()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &http.Response{
+		StatusCode: r.status,
+		Header:     r.headers,
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+	}, nil
+}
+
+func (f *fakeClient) Get(path string, response any) error {
+	f.bodies = append(f.bodies, "")
+	r := f.next()
+	if r.err != nil {
+		return r.err
+	}
+	return json.Unmarshal([]byte(r.body), response)
+}
+
+func (f *fakeClient) Do(query string, variables map[string]any, response any) error {
+	return f.Get("graphql", response)
+}
+
+// newTestClient は待ち時間を記録するだけの Client を作成します
+func newTestClient(fake *fakeClient, now time.Time) (*Client, *[]time.Duration) {
+	var sleeps []time.Duration
+	c := New(fake)
+	c.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	c.now = func() time.Time { return now }
+	return c, &sleeps
+}
+
+func TestClientRequest(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	reset := strconv.FormatInt(now.Add(10*time.Second).Unix(), 10)
+	farReset := strconv.FormatInt(now.Add(time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		responses  []scripted
+		wantStatus int
+		wantErr    bool
+		wantCalls  int
+		wantSleeps []time.Duration
+	}{
+		{
+			name:   "GET retries 5xx with backoff",
+			method: http.MethodGet,
+			responses: []scripted{
+				{status: 502},
+				{status: 503},
+				{status: 200, body: `{}`},
+			},
+			wantStatus: 200,
+			wantCalls:  3,
+			wantSleeps: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:   "GET gives up after max retries",
+			method: http.MethodGet,
+			responses: []scripted{
+				{status: 500}, {status: 500}, {status: 500}, {status: 500},
+			},
+			wantStatus: 500,
+			wantCalls:  4,
+			wantSleeps: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:       "GET does not retry 4xx",
+			method:     http.MethodGet,
+			responses:  []scripted{{status: 404}},
+			wantStatus: 404,
+			wantCalls:  1,
+		},
+		{
+			name:       "POST does not retry 5xx",
+			method:     http.MethodPost,
+			body:       `{"ref":"main"}`,
+			responses:  []scripted{{status: 500}},
+			wantStatus: 500,
+			wantCalls:  1,
+		},
+		{
+			name:      "POST does not retry network errors",
+			method:    http.MethodPost,
+			body:      `{"ref":"main"}`,
+			responses: []scripted{{err: fmt.Errorf("connection reset")}},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:   "POST retries secondary rate limit after Retry-After",
+			method: http.MethodPost,
+			body:   `{"ref":"main"}`,
+			responses: []scripted{
+				{status: 403, headers: http.Header{"Retry-After": []string{"5"}}, body: `{"message":"You have exceeded a secondary rate limit."}`},
+				{status: 204},
+			},
+			wantStatus: 204,
+			wantCalls:  2,
+			wantSleeps: []time.Duration{5 * time.Second},
+		},
+		{
+			name:   "waits until X-RateLimit-Reset",
+			method: http.MethodPost,
+			body:   `{"ref":"main"}`,
+			responses: []scripted{
+				{status: 429, headers: http.Header{"X-Ratelimit-Remaining": []string{"0"}, "X-Ratelimit-Reset": []string{reset}}},
+				{status: 204},
+			},
+			wantStatus: 204,
+			wantCalls:  2,
+			wantSleeps: []time.Duration{10 * time.Second},
+		},
+		{
+			name:   "does not wait for a distant reset",
+			method: http.MethodGet,
+			responses: []scripted{
+				{status: 403, headers: http.Header{"X-Ratelimit-Remaining": []string{"0"}, "X-Ratelimit-Reset": []string{farReset}}},
+			},
+			wantStatus: 403,
+			wantCalls:  1,
+		},
+		{
+			name:   "retries HTTPError from go-gh",
+			method: http.MethodGet,
+			responses: []scripted{
+				{err: &api.HTTPError{StatusCode: 502, Message: "Bad Gateway"}},
+				{status: 200, body: `{}`},
+			},
+			wantStatus: 200,
+			wantCalls:  2,
+			wantSleeps: []time.Duration{time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeClient{responses: tt.responses}
+			c, sleeps := newTestClient(fake, now)
+
+			var body io.Reader
+			if tt.body != "" {
+				body = bytes.NewBufferString(tt.body)
+			}
+			resp, err := c.Request(tt.method, "repos/user/repo/actions/workflows/test.yml/dispatches", body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Request() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if resp != nil && resp.StatusCode != tt.wantStatus {
+				t.Errorf("Request() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if len(fake.bodies) != tt.wantCalls {
+				t.Errorf("Request() made %d calls, want %d", len(fake.bodies), tt.wantCalls)
+			}
+			for i, b := range fake.bodies {
+				if b != tt.body {
+					t.Errorf("Request() call %d body = %q, want %q", i, b, tt.body)
+				}
+			}
+			if !reflect.DeepEqual(*sleeps, tt.wantSleeps) {
+				t.Errorf("Request() sleeps = %v, want %v", *sleeps, tt.wantSleeps)
+			}
+		})
+	}
+}
+
+func TestClientRequestKeepsErrorBody(t *testing.T) {
+	fake := &fakeClient{responses: []scripted{{status: 422, body: `{"message":"No ref found for: x"}`}}}
+	c, _ := newTestClient(fake, time.Now())
+
+	resp, err := c.Request(http.MethodPost, "dispatches", bytes.NewBufferString("{}"))
+	if err != nil {
+		t.Fatalf("Request() unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"message":"No ref found for: x"}` {
+		t.Errorf("Request() body = %q, want the original error body", body)
+	}
+}
+
+func TestClientGet(t *testing.T) {
+	fake := &fakeClient{responses: []scripted{
+		{err: &api.HTTPError{StatusCode: 503}},
+		{err: &api.HTTPError{StatusCode: 403, Message: "API rate limit exceeded", Headers: http.Header{"Retry-After": []string{"3"}}}},
+		{body: `{"login":"alice"}`},
+	}}
+	c, sleeps := newTestClient(fake, time.Now())
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := c.Get("user", &user); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if user.Login != "alice" {
+		t.Errorf("Get() login = %q, want alice", user.Login)
+	}
+	if want := []time.Duration{time.Second, 3 * time.Second}; !reflect.DeepEqual(*sleeps, want) {
+		t.Errorf("Get() sleeps = %v, want %v", *sleeps, want)
+	}
+
+	fake = &fakeClient{responses: []scripted{{err: &api.HTTPError{StatusCode: 404}}}}
+	c, _ = newTestClient(fake, time.Now())
+	if err := c.Get("user", &user); err == nil {
+		t.Error("Get() expected error for 404, got nil")
+	}
+	if len(fake.bodies) != 1 {
+		t.Errorf("Get() made %d calls for 404, want 1", len(fake.bodies))
+	}
+}
+
+func TestGraphQLDo(t *testing.T) {
+	fake := &fakeClient{responses: []scripted{
+		{err: &api.HTTPError{StatusCode: 502}},
+		{err: &api.HTTPError{StatusCode: 403, Message: "You have exceeded a secondary rate limit", Headers: http.Header{"Retry-After": []string{"5"}}}},
+		{body: `{"repository":{"name":"repo"}}`},
+	}}
+	var sleeps []time.Duration
+	g := NewGraphQL(fake)
+	g.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	var response struct {
+		Repository struct {
+			Name string `json:"name"`
+		} `json:"repository"`
+	}
+	if err := g.Do("query", nil, &response); err != nil {
+		t.Fatalf("Do() unexpected error: %v", err)
+	}
+	if response.Repository.Name != "repo" {
+		t.Errorf("Do() repository = %q, want repo", response.Repository.Name)
+	}
+	if want := []time.Duration{time.Second, 5 * time.Second}; !reflect.DeepEqual(sleeps, want) {
+		t.Errorf("Do() sleeps = %v, want %v", sleeps, want)
+	}
+
+	// クエリ自体のエラーは再試行しても結果が変わらない
+	fake = &fakeClient{responses: []scripted{{err: &api.GraphQLError{Errors: []api.GraphQLErrorItem{{Message: "Could not resolve to a Repository"}}}}}}
+	g = NewGraphQL(fake)
+	if err := g.Do("query", nil, &response); err == nil {
+		t.Error("Do() expected error for a GraphQL error, got nil")
+	}
+	if len(fake.bodies) != 1 {
+		t.Errorf("Do() made %d calls for a GraphQL error, want 1", len(fake.bodies))
+	}
+}
@@ -0,0 +1,28 @@
+package shellquote
+
+import (
+	"regexp"
+	"strings"
+)
+
+// safePattern はクォートせずにシェルへ渡せる文字だけからなる文字列にマッチします
+var safePattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Quote は s を POSIX シェルで 1 つの引数として解釈されるようにクォートします
+// クォートが不要な場合はそのまま返します
+func Quote(s string) string {
+	if safePattern.MatchString(s) {
+		return s
+	}
+	// シングルクォート内ではエスケープできないため、' は '\'' として閉じて埋め込む
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join は args をそれぞれクォートし、空白区切りのコマンドラインにします
+func Join(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,36 @@
+package shellquote
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "deploy.yml", want: "deploy.yml"},
+		{name: "key value", in: "ref=feature/login", want: "ref=feature/login"},
+		{name: "empty", in: "", want: "''"},
+		{name: "space", in: "hello world", want: "'hello world'"},
+		{name: "brackets", in: "inputs[env]=prod", want: "'inputs[env]=prod'"},
+		{name: "single quote", in: "it's", want: `'it'\''s'`},
+		{name: "shell characters", in: "$(rm -rf /); `x` | y", want: "'$(rm -rf /); `x` | y'"},
+		{name: "unicode", in: "デプロイ", want: "'デプロイ'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Quote(tt.in); got != tt.want {
+				t.Errorf("Quote(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	got := Join("gh", "api", "--method", "POST", "-f", "inputs[message]=hello world")
+	want := "gh api --method POST -f 'inputs[message]=hello world'"
+	if got != want {
+		t.Errorf("Join() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,174 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// ErrorKind は GitHub API のエラーの種類です
+type ErrorKind string
+
+// GitHub API のエラーの種類
+const (
+	ErrorKindUnknown           ErrorKind = "unknown"
+	ErrorKindUnexpectedInputs  ErrorKind = "unexpected_inputs"   // ワークフローに定義されていない input が渡された
+	ErrorKindInvalidInput      ErrorKind = "invalid_input"       // input の値が不正、または必須の input がない
+	ErrorKindRefNotFound       ErrorKind = "ref_not_found"       // ref が GitHub 上に存在しない
+	ErrorKindNoDispatchTrigger ErrorKind = "no_dispatch_trigger" // ref 上のワークフローに workflow_dispatch がない
+	ErrorKindWorkflowDisabled  ErrorKind = "workflow_disabled"   // ワークフローが無効化されている
+	ErrorKindMissingScope      ErrorKind = "missing_scope"       // トークンに必要なスコープがない
+	ErrorKindForbidden         ErrorKind = "forbidden"           // トークンに必要な権限がない
+	ErrorKindNotFound          ErrorKind = "not_found"           // リポジトリまたはワークフローが見つからない
+)
+
+// APIError は GitHub API のエラーレスポンスを種類ごとに分類したものです
+type APIError struct {
+	StatusCode       int
+	Kind             ErrorKind
+	Message          string   // GitHub が返したエラーメッセージ
+	DocumentationURL string   // GitHub が返したドキュメントの URL
+	Inputs           []string // ErrorKindUnexpectedInputs の場合の input 名
+	MissingScopes    []string // ErrorKindMissingScope の場合に不足しているスコープ
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// Hint はエラーの解決方法を人が読める形で返します。分からない場合は空文字を返します
+func (e *APIError) Hint() string {
+	switch e.Kind {
+	case ErrorKindUnexpectedInputs:
+		return fmt.Sprintf("The workflow file on the selected ref does not define %s. Check on.workflow_dispatch.inputs on that ref, or push the change that adds them.", strings.Join(e.Inputs, ", "))
+	case ErrorKindInvalidInput:
+		return "Check the value against the input's type and options in the workflow file on the selected ref."
+	case ErrorKindRefNotFound:
+		return "The branch or tag does not exist on GitHub. Push it first, or choose another ref."
+	case ErrorKindNoDispatchTrigger:
+		return "The workflow file on the selected ref has no workflow_dispatch trigger. Push the change that adds it, or choose another ref."
+	case ErrorKindWorkflowDisabled:
+		return "Enable the workflow with `gh workflow enable` or from the repository's Actions tab."
+	case ErrorKindMissingScope:
+		return fmt.Sprintf("Your token is missing the %s scope. Run `gh auth refresh -s %s` and try again.", strings.Join(e.MissingScopes, ", "), strings.Join(e.MissingScopes, ","))
+	case ErrorKindForbidden:
+		return "Your token is not allowed to run workflows in this repository. Fine-grained tokens and GitHub Apps need the \"Actions: write\" permission."
+	case ErrorKindNotFound:
+		return "Check the repository name and that the workflow file exists on the default branch. Run `gh auth status` to check that your token can access the repository."
+	}
+	return ""
+}
+
+// unexpectedInputsPattern は "Unexpected inputs provided: [\"a\", \"b\"]" から input 名の一覧を取り出します
+var unexpectedInputsPattern = regexp.MustCompile(`(?i)unexpected inputs provided:\s*(\[.*\])`)
+
+// newAPIError はステータスコード・ヘッダー・メッセージからエラーの種類を判定します
+func newAPIError(statusCode int, headers http.Header, message, documentationURL string) *APIError {
+	e := &APIError{
+		StatusCode:       statusCode,
+		Kind:             ErrorKindUnknown,
+		Message:          message,
+		DocumentationURL: documentationURL,
+	}
+
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "unexpected inputs provided"):
+		e.Kind = ErrorKindUnexpectedInputs
+		if m := unexpectedInputsPattern.FindStringSubmatch(message); m != nil {
+			_ = json.Unmarshal([]byte(m[1]), &e.Inputs)
+		}
+	case strings.Contains(lower, "required input") || strings.Contains(lower, "for input") || strings.Contains(lower, "allowed values"):
+		e.Kind = ErrorKindInvalidInput
+	case strings.Contains(lower, "no ref found"):
+		e.Kind = ErrorKindRefNotFound
+	case strings.Contains(lower, "workflow_dispatch' trigger"):
+		e.Kind = ErrorKindNoDispatchTrigger
+	case strings.Contains(lower, "disabled workflow") || strings.Contains(lower, "workflow is disabled"):
+		e.Kind = ErrorKindWorkflowDisabled
+	case strings.Contains(lower, "`workflow` scope"):
+		e.Kind = ErrorKindMissingScope
+		e.MissingScopes = []string{"workflow"}
+	default:
+		if scopes := missingScopes(headers); len(scopes) > 0 && (statusCode == http.StatusForbidden || statusCode == http.StatusNotFound) {
+			e.Kind = ErrorKindMissingScope
+			e.MissingScopes = scopes
+		} else if statusCode == http.StatusForbidden {
+			e.Kind = ErrorKindForbidden
+		} else if statusCode == http.StatusNotFound {
+			e.Kind = ErrorKindNotFound
+		}
+	}
+	return e
+}
+
+// missingScopes は OAuth トークンのスコープのヘッダーから、不足しているスコープを返します
+// X-Accepted-OAuth-Scopes のいずれかを持っていれば不足はないものとします
+func missingScopes(headers http.Header) []string {
+	if headers == nil || headers.Get("X-Accepted-OAuth-Scopes") == "" {
+		return nil
+	}
+	// X-OAuth-Scopes がない場合は OAuth トークン以外 (GitHub App など) なので判定しない
+	if _, ok := headers[http.CanonicalHeaderKey("X-OAuth-Scopes")]; !ok {
+		return nil
+	}
+
+	accepted := splitScopes(headers.Get("X-Accepted-OAuth-Scopes"))
+	granted := splitScopes(headers.Get("X-OAuth-Scopes"))
+	for _, scope := range accepted {
+		if slices.Contains(granted, scope) {
+			return nil
+		}
+	}
+	return accepted
+}
+
+// splitScopes はカンマ区切りのスコープ一覧を分割します
+func splitScopes(s string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(s, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// parseAPIError はエラーレスポンスを APIError に変換します
+// メッセージは go-gh の HTTPError と同じ規則で組み立て、go-gh が読み捨てる documentation_url は本文から読み取ります
+func parseAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var payload struct {
+		DocumentationURL string `json:"documentation_url"`
+	}
+	_ = json.Unmarshal(body, &payload)
+
+	apiErr, _ := asAPIError(api.HandleHTTPError(resp))
+	apiErr.DocumentationURL = payload.DocumentationURL
+	return apiErr
+}
+
+// asAPIError は go-gh の HTTPError を APIError に変換します。HTTPError でない場合は false を返します
+// HTTPError のメッセージには errors[] の各メッセージが改行区切りで含まれています
+func asAPIError(err error) (*APIError, bool) {
+	var httpErr *api.HTTPError
+	if !errors.As(err, &httpErr) {
+		return nil, false
+	}
+
+	message := strings.ReplaceAll(httpErr.Message, "\n", "; ")
+	return newAPIError(httpErr.StatusCode, httpErr.Headers, message, ""), true
+}
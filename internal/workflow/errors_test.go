@@ -0,0 +1,198 @@
+package workflow
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+func TestRunDispatchAPIError(t *testing.T) {
+	params := DispatchParams{Owner: "user", Repo: "repo", WorkflowFile: "test.yml", Ref: "main"}
+
+	tests := []struct {
+		name       string
+		code       int
+		body       string
+		wantKind   ErrorKind
+		wantMsg    string
+		wantDocURL string
+		wantInputs []string
+	}{
+		{
+			name:       "unexpected inputs",
+			code:       422,
+			body:       `{"message": "Unexpected inputs provided: [\"foo\", \"bar\"]", "documentation_url": "https://docs.github.com/rest/actions/workflows#create-a-workflow-dispatch-event"}`,
+			wantKind:   ErrorKindUnexpectedInputs,
+			wantMsg:    `Unexpected inputs provided: ["foo", "bar"] (HTTP 422)`,
+			wantDocURL: "https://docs.github.com/rest/actions/workflows#create-a-workflow-dispatch-event",
+			wantInputs: []string{"foo", "bar"},
+		},
+		{
+			name:     "invalid choice",
+			code:     422,
+			body:     `{"message": "Provided value 'qa' for input 'environment' not in the list of allowed values"}`,
+			wantKind: ErrorKindInvalidInput,
+			wantMsg:  "Provided value 'qa' for input 'environment' not in the list of allowed values (HTTP 422)",
+		},
+		{
+			name:     "no ref",
+			code:     422,
+			body:     `{"message": "No ref found for: feature"}`,
+			wantKind: ErrorKindRefNotFound,
+			wantMsg:  "No ref found for: feature (HTTP 422)",
+		},
+		{
+			name:     "no dispatch trigger",
+			code:     422,
+			body:     `{"message": "Workflow does not have 'workflow_dispatch' trigger"}`,
+			wantKind: ErrorKindNoDispatchTrigger,
+			wantMsg:  "Workflow does not have 'workflow_dispatch' trigger (HTTP 422)",
+		},
+		{
+			name:     "disabled workflow",
+			code:     422,
+			body:     `{"message": "Cannot trigger a 'workflow_dispatch' on a disabled workflow"}`,
+			wantKind: ErrorKindWorkflowDisabled,
+			wantMsg:  "Cannot trigger a 'workflow_dispatch' on a disabled workflow (HTTP 422)",
+		},
+		{
+			name:     "not found",
+			code:     404,
+			body:     `{"message": "Not Found"}`,
+			wantKind: ErrorKindNotFound,
+			wantMsg:  "Not Found (HTTP 404)",
+		},
+		{
+			name:       "error items",
+			code:       422,
+			body:       `{"message": "Validation Failed", "errors": [{"resource": "Workflow", "field": "ref", "code": "invalid"}], "documentation_url": "https://docs.github.com/rest"}`,
+			wantKind:   ErrorKindUnknown,
+			wantMsg:    "Validation Failed; Workflow.ref is invalid (HTTP 422)",
+			wantDocURL: "https://docs.github.com/rest",
+		},
+		{
+			name:     "body is not json",
+			code:     502,
+			body:     `<html>Bad Gateway</html>`,
+			wantKind: ErrorKindUnknown,
+			wantMsg:  "unexpected status code: 502",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockRESTClient{ResponseCode: tt.code, ResponseBody: tt.body}
+
+			_, err := RunDispatch(client, params)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("RunDispatch() error = %v, want *APIError", err)
+			}
+			if apiErr.Kind != tt.wantKind {
+				t.Errorf("Kind = %q, want %q", apiErr.Kind, tt.wantKind)
+			}
+			if apiErr.Error() != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", apiErr.Error(), tt.wantMsg)
+			}
+			if apiErr.DocumentationURL != tt.wantDocURL {
+				t.Errorf("DocumentationURL = %q, want %q", apiErr.DocumentationURL, tt.wantDocURL)
+			}
+			if !reflect.DeepEqual(apiErr.Inputs, tt.wantInputs) {
+				t.Errorf("Inputs = %v, want %v", apiErr.Inputs, tt.wantInputs)
+			}
+			if (apiErr.Hint() != "") != (tt.wantKind != ErrorKindUnknown) {
+				t.Errorf("Hint() = %q for kind %q", apiErr.Hint(), apiErr.Kind)
+			}
+		})
+	}
+}
+
+func TestRunDispatchHTTPError(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       int
+		header     http.Header
+		body       string
+		wantKind   ErrorKind
+		wantMsg    string
+		wantScopes []string
+		wantHint   string
+	}{
+		{
+			name: "missing scope from headers",
+			code: 404,
+			header: http.Header{
+				"X-Accepted-Oauth-Scopes": []string{"repo"},
+				"X-Oauth-Scopes":          []string{"read:org, gist"},
+			},
+			body:       `{"message": "Not Found"}`,
+			wantKind:   ErrorKindMissingScope,
+			wantMsg:    "Not Found (HTTP 404)",
+			wantScopes: []string{"repo"},
+			wantHint:   "Your token is missing the repo scope. Run `gh auth refresh -s repo` and try again.",
+		},
+		{
+			name: "scope granted is a plain not found",
+			code: 404,
+			header: http.Header{
+				"X-Accepted-Oauth-Scopes": []string{"repo"},
+				"X-Oauth-Scopes":          []string{"repo, workflow"},
+			},
+			body:     `{"message": "Not Found"}`,
+			wantKind: ErrorKindNotFound,
+			wantMsg:  "Not Found (HTTP 404)",
+		},
+		{
+			name:       "workflow scope in message",
+			code:       403,
+			body:       `{"message": "refusing to allow an OAuth App to create or update workflow without ` + "`workflow`" + ` scope"}`,
+			wantKind:   ErrorKindMissingScope,
+			wantMsg:    "refusing to allow an OAuth App to create or update workflow without `workflow` scope (HTTP 403)",
+			wantScopes: []string{"workflow"},
+			wantHint:   "Your token is missing the workflow scope. Run `gh auth refresh -s workflow` and try again.",
+		},
+		{
+			name:     "integration without permission",
+			code:     403,
+			body:     `{"message": "Resource not accessible by integration"}`,
+			wantKind: ErrorKindForbidden,
+			wantMsg:  "Resource not accessible by integration (HTTP 403)",
+		},
+		{
+			name:     "error items are listed once",
+			code:     422,
+			body:     `{"message": "Validation Failed", "errors": [{"message": "Required input 'version' not provided"}, "ref is invalid"]}`,
+			wantKind: ErrorKindInvalidInput,
+			wantMsg:  "Validation Failed; Required input 'version' not provided; ref is invalid (HTTP 422)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// go-gh の RESTClient と同じく、エラーレスポンスを HTTPError に変換して返す
+			resp := newResponse(http.MethodPost, "repos/user/repo/actions/workflows/test.yml/dispatches", tt.code, tt.header, tt.body)
+			client := &mockRESTClient{Error: api.HandleHTTPError(resp)}
+
+			_, err := RunDispatch(client, DispatchParams{Owner: "user", Repo: "repo", WorkflowFile: "test.yml", Ref: "main"})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("RunDispatch() error = %v, want *APIError", err)
+			}
+			if apiErr.Kind != tt.wantKind {
+				t.Errorf("Kind = %q, want %q", apiErr.Kind, tt.wantKind)
+			}
+			if apiErr.Error() != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", apiErr.Error(), tt.wantMsg)
+			}
+			if !reflect.DeepEqual(apiErr.MissingScopes, tt.wantScopes) {
+				t.Errorf("MissingScopes = %v, want %v", apiErr.MissingScopes, tt.wantScopes)
+			}
+			if tt.wantHint != "" && apiErr.Hint() != tt.wantHint {
+				t.Errorf("Hint() = %q, want %q", apiErr.Hint(), tt.wantHint)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// Run はワークフローの実行 (workflow run) を表します
+type Run struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	HTMLURL    string    `json:"html_url"`
+	Event      string    `json:"event"`
+	HeadBranch string    `json:"head_branch"`
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	StartedAt  time.Time `json:"run_started_at"`
+}
+
+// Job はワークフロー実行のジョブを表します
+type Job struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Conclusion  string    `json:"conclusion"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Steps       []Step    `json:"steps"`
+}
+
+// Step はジョブのステップを表します
+type Step struct {
+	Number      int       `json:"number"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Conclusion  string    `json:"conclusion"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// 実行・ジョブ・ステップの status
+const (
+	StatusQueued     = "queued"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+)
+
+// Completed は実行が完了しているか判定します
+func (r Run) Completed() bool {
+	return r.Status == StatusCompleted
+}
+
+// ExitCode は実行の conclusion に対応する終了コードを返します
+// success・neutral・skipped は 0、それ以外 (failure, cancelled, timed_out など) は 1 です
+func (r Run) ExitCode() int {
+	switch r.Conclusion {
+	case "success", "neutral", "skipped":
+		return 0
+	}
+	return 1
+}
+
+// RunQuery はディスパッチによって作成された実行を検索する条件です
+type RunQuery struct {
+	Owner        string
+	Repo         string
+	WorkflowFile string
+	Ref          string
+	Actor        string    // 空の場合は実行者で絞り込みません
+	Since        time.Time // ディスパッチする直前の時刻
+}
+
+// 作成された実行を探すときのポーリング設定 (テストで変更できるよう変数にしています)
+var (
+	findRunAttempts = 10
+	findRunInterval = 2 * time.Second
+)
+
+// FindDispatchedRun はディスパッチによって作成された実行を探します
+// 実行が API から見えるようになるまで時間がかかるため、見つかるまで一定回数ポーリングします
+func FindDispatchedRun(client RESTClient, q RunQuery) (*Run, error) {
+	query := url.Values{}
+	query.Set("event", "workflow_dispatch")
+	query.Set("branch", q.Ref)
+	query.Set("created", ">="+q.Since.UTC().Format(time.RFC3339))
+	query.Set("per_page", "20")
+	if q.Actor != "" {
+		query.Set("actor", q.Actor)
+	}
+	path := fmt.Sprintf("repos/%s/%s/actions/workflows/%s/runs?%s",
+		q.Owner, q.Repo, url.PathEscape(q.WorkflowFile), query.Encode())
+
+	for attempt := 0; attempt < findRunAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(findRunInterval)
+		}
+
+		runs, err := listRuns(client, path)
+		if err != nil {
+			return nil, err
+		}
+
+		// 条件に合う実行のうち、ディスパッチ後に最初に作成されたものを選ぶ
+		var found *Run
+		for i, run := range runs {
+			if run.CreatedAt.Before(q.Since) {
+				continue
+			}
+			if found == nil || run.CreatedAt.Before(found.CreatedAt) {
+				found = &runs[i]
+			}
+		}
+		if found != nil {
+			return found, nil
+		}
+	}
+
+	return nil, fmt.Errorf("could not find the workflow run created by the dispatch")
+}
+
+// listRuns はワークフロー実行の一覧を取得します
+func listRuns(client RESTClient, path string) ([]Run, error) {
+	var result struct {
+		WorkflowRuns []Run `json:"workflow_runs"`
+	}
+	if err := getJSON(client, path, &result); err != nil {
+		return nil, fmt.Errorf("failed to list workflow runs: %w", err)
+	}
+	return result.WorkflowRuns, nil
+}
+
+// GetRun はワークフロー実行の情報を取得します
+func GetRun(client RESTClient, owner, repo string, id int64) (*Run, error) {
+	path := fmt.Sprintf("repos/%s/%s/actions/runs/%d", owner, repo, id)
+
+	var run Run
+	if err := getJSON(client, path, &run); err != nil {
+		return nil, fmt.Errorf("failed to get workflow run: %w", err)
+	}
+	return &run, nil
+}
+
+// ListJobs はワークフロー実行のジョブ一覧をステップ込みで取得します
+func ListJobs(client RESTClient, owner, repo string, id int64) ([]Job, error) {
+	path := fmt.Sprintf("repos/%s/%s/actions/runs/%d/jobs?per_page=100", owner, repo, id)
+
+	var result struct {
+		Jobs []Job `json:"jobs"`
+	}
+	if err := getJSON(client, path, &result); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return result.Jobs, nil
+}
+
+// getJSON は GET リクエストを行い、レスポンスの JSON を response にデコードします
+func getJSON(client RESTClient, path string, response any) error {
+	resp, err := client.Request(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return api.HandleHTTPError(resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
@@ -0,0 +1,143 @@
+package workflow
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// sequenceRESTClient は呼び出しごとに用意したレスポンスを順に返すモックです
+type sequenceRESTClient struct {
+	Bodies []string
+	Paths  []string
+}
+
+func (m *sequenceRESTClient) Request(method string, path string, body io.Reader) (*http.Response, error) {
+	m.Paths = append(m.Paths, path)
+	idx := min(len(m.Paths)-1, len(m.Bodies)-1)
+	return &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader([]byte(m.Bodies[idx]))),
+	}, nil
+}
+
+func TestFindDispatchedRun(t *testing.T) {
+	findRunInterval = 0
+	t.Cleanup(func() { findRunInterval = 2 * time.Second })
+
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	query := RunQuery{
+		Owner:        "user",
+		Repo:         "repo",
+		WorkflowFile: "deploy.yml",
+		Ref:          "main",
+		Actor:        "octocat",
+		Since:        since,
+	}
+
+	client := &sequenceRESTClient{
+		Bodies: []string{
+			`{"total_count": 0, "workflow_runs": []}`,
+			`{"total_count": 3, "workflow_runs": [
+				{"id": 3, "html_url": "https://github.com/user/repo/actions/runs/3", "created_at": "2026-01-02T03:04:30Z"},
+				{"id": 2, "html_url": "https://github.com/user/repo/actions/runs/2", "created_at": "2026-01-02T03:04:06Z"},
+				{"id": 1, "html_url": "https://github.com/user/repo/actions/runs/1", "created_at": "2026-01-02T03:04:00Z"}
+			]}`,
+		},
+	}
+
+	run, err := FindDispatchedRun(client, query)
+	if err != nil {
+		t.Fatalf("FindDispatchedRun() unexpected error: %v", err)
+	}
+	if run.ID != 2 || run.HTMLURL != "https://github.com/user/repo/actions/runs/2" {
+		t.Errorf("FindDispatchedRun() = %+v, want run 2", run)
+	}
+
+	if len(client.Paths) != 2 {
+		t.Fatalf("FindDispatchedRun() made %d requests, want 2", len(client.Paths))
+	}
+	u, err := url.Parse(client.Paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "repos/user/repo/actions/workflows/deploy.yml/runs" {
+		t.Errorf("request path = %q", u.Path)
+	}
+	want := url.Values{
+		"event":    {"workflow_dispatch"},
+		"branch":   {"main"},
+		"created":  {">=2026-01-02T03:04:05Z"},
+		"actor":    {"octocat"},
+		"per_page": {"20"},
+	}
+	if got := u.Query(); got.Encode() != want.Encode() {
+		t.Errorf("request query = %v, want %v", got, want)
+	}
+}
+
+func TestFindDispatchedRunNotFound(t *testing.T) {
+	findRunInterval = 0
+	t.Cleanup(func() { findRunInterval = 2 * time.Second })
+
+	client := &sequenceRESTClient{Bodies: []string{`{"total_count": 0, "workflow_runs": []}`}}
+
+	_, err := FindDispatchedRun(client, RunQuery{Owner: "user", Repo: "repo", WorkflowFile: "deploy.yml", Ref: "main"})
+	if err == nil || err.Error() != "could not find the workflow run created by the dispatch" {
+		t.Errorf("FindDispatchedRun() error = %v, want not found", err)
+	}
+	if len(client.Paths) != findRunAttempts {
+		t.Errorf("FindDispatchedRun() made %d requests, want %d", len(client.Paths), findRunAttempts)
+	}
+}
+
+func TestListJobs(t *testing.T) {
+	client := &sequenceRESTClient{
+		Bodies: []string{`{"total_count": 1, "jobs": [
+			{"id": 7, "name": "build", "status": "completed", "conclusion": "success",
+			 "started_at": "2026-01-02T03:04:05Z", "completed_at": "2026-01-02T03:05:05Z",
+			 "steps": [{"number": 1, "name": "Set up job", "status": "completed", "conclusion": "success"}]},
+			{"id": 8, "name": "deploy", "status": "queued", "conclusion": null, "started_at": null, "steps": []}
+		]}`},
+	}
+
+	jobs, err := ListJobs(client, "user", "repo", 42)
+	if err != nil {
+		t.Fatalf("ListJobs() unexpected error: %v", err)
+	}
+	if client.Paths[0] != "repos/user/repo/actions/runs/42/jobs?per_page=100" {
+		t.Errorf("request path = %q", client.Paths[0])
+	}
+	if len(jobs) != 2 || jobs[0].Name != "build" || len(jobs[0].Steps) != 1 || jobs[0].CompletedAt.Sub(jobs[0].StartedAt) != time.Minute {
+		t.Errorf("ListJobs() = %+v", jobs)
+	}
+	if jobs[1].Status != StatusQueued || !jobs[1].StartedAt.IsZero() {
+		t.Errorf("ListJobs() queued job = %+v", jobs[1])
+	}
+}
+
+func TestRunExitCode(t *testing.T) {
+	tests := []struct {
+		conclusion string
+		want       int
+	}{
+		{"success", 0},
+		{"skipped", 0},
+		{"neutral", 0},
+		{"failure", 1},
+		{"cancelled", 1},
+		{"timed_out", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.conclusion, func(t *testing.T) {
+			run := Run{Status: StatusCompleted, Conclusion: tt.conclusion}
+			if got := run.ExitCode(); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,11 +3,17 @@ package workflow
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -24,8 +30,19 @@ type Workflow struct {
 	Path     string
 	FileName string
 	Inputs   map[string]Input
+	// InputNames は Inputs のキーをワークフローファイルに記述された順に並べたものです
+	InputNames []string
 }
 
+// workflow_dispatch の input で指定できる type
+const (
+	InputTypeString      = "string"
+	InputTypeBoolean     = "boolean"
+	InputTypeChoice      = "choice"
+	InputTypeNumber      = "number"
+	InputTypeEnvironment = "environment"
+)
+
 // Input はworkflow_dispatchのinput定義を表します
 type Input struct {
 	Description string   `yaml:"description"`
@@ -48,6 +65,35 @@ type DispatchParams struct {
 	WorkflowFile string
 	Ref          string
 	Inputs       map[string]string
+	Schema       map[string]Input // 指定された場合は Inputs をこの定義で検証します
+	// ReturnRunDetails は作成された実行の情報をレスポンスで返すよう要求します
+	// (対応していない GitHub Enterprise Server では false にします)
+	ReturnRunDetails bool
+}
+
+// InputError は1つの input に対する検証エラーを表します
+type InputError struct {
+	Name    string
+	Message string
+}
+
+func (e InputError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Message)
+}
+
+// ValidationError は inputs の検証で見つかったすべてのエラーを表します
+type ValidationError struct {
+	Errors []InputError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid inputs:")
+	for _, ie := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(ie.Error())
+	}
+	return b.String()
 }
 
 // LoadDispatchableWorkflows は指定ディレクトリ内の workflow_dispatch を持つワークフローを検索します
@@ -82,7 +128,7 @@ func LoadDispatchableWorkflows(workflowsDir string) ([]Workflow, error) {
 			continue
 		}
 
-		inputs := extractInputs(wf.On)
+		inputs := extractInputs(content)
 		if inputs != nil || hasWorkflowDispatch(wf.On) {
 			title := wf.Name
 			if title == "" {
@@ -93,10 +139,11 @@ func LoadDispatchableWorkflows(workflowsDir string) ([]Workflow, error) {
 			relativePath := filepath.Join(".github", "workflows", entry.Name())
 
 			workflows = append(workflows, Workflow{
-				Name:     title,
-				Path:     relativePath,
-				FileName: entry.Name(),
-				Inputs:   inputs,
+				Name:       title,
+				Path:       relativePath,
+				FileName:   entry.Name(),
+				Inputs:     inputs,
+				InputNames: inputNames(content, inputs),
 			})
 		}
 	}
@@ -104,6 +151,107 @@ func LoadDispatchableWorkflows(workflowsDir string) ([]Workflow, error) {
 	return workflows, nil
 }
 
+// FindWorkflow はファイル名 (拡張子は省略可) または表示名でワークフローを検索します
+func FindWorkflow(workflows []Workflow, name string) (Workflow, error) {
+	for _, wf := range workflows {
+		if wf.FileName == name || wf.Path == name {
+			return wf, nil
+		}
+	}
+	for _, wf := range workflows {
+		if strings.TrimSuffix(wf.FileName, filepath.Ext(wf.FileName)) == name {
+			return wf, nil
+		}
+	}
+
+	var matches []Workflow
+	for _, wf := range workflows {
+		if strings.EqualFold(wf.Name, name) {
+			matches = append(matches, wf)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Workflow{}, fmt.Errorf("workflow %q not found", name)
+	case 1:
+		return matches[0], nil
+	}
+
+	files := make([]string, 0, len(matches))
+	for _, wf := range matches {
+		files = append(files, wf.FileName)
+	}
+	return Workflow{}, fmt.Errorf("workflow name %q is ambiguous, specify one of: %s", name, strings.Join(files, ", "))
+}
+
+// ApplyDefaults は値が指定されていない input にデフォルト値を補完した map を返します
+func ApplyDefaults(schema map[string]Input, values map[string]string) map[string]string {
+	result := make(map[string]string, len(values))
+	for name, value := range values {
+		result[name] = value
+	}
+	for name, input := range schema {
+		if _, ok := result[name]; !ok && input.Default != "" {
+			result[name] = input.Default
+		}
+	}
+	return result
+}
+
+// HasEnvironmentInput は type: environment の input があるかを返します
+func HasEnvironmentInput(schema map[string]Input) bool {
+	for _, input := range schema {
+		if input.Type == InputTypeEnvironment {
+			return true
+		}
+	}
+	return false
+}
+
+// WithEnvironments は type: environment の input の Options に環境名を設定した schema のコピーを返します
+// 環境が 1 つもない場合は、値を検証できないため schema をそのまま返します
+func WithEnvironments(schema map[string]Input, names []string) map[string]Input {
+	if len(names) == 0 || !HasEnvironmentInput(schema) {
+		return schema
+	}
+
+	result := make(map[string]Input, len(schema))
+	for name, input := range schema {
+		if input.Type == InputTypeEnvironment {
+			input.Options = slices.Clone(names)
+		}
+		result[name] = input
+	}
+	return result
+}
+
+// ParseInputValues は JSON または YAML で書かれた input 名と値の map を読み込みます
+// 値はスカラーのみ受け付け、ワークフローの default と同じ形式の文字列として返します
+func ParseInputValues(r io.Reader) (map[string]string, error) {
+	var raw map[string]yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&raw); err != nil {
+		if err == io.EOF {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to parse input values: %w", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for name, node := range raw {
+		node = *resolveAlias(&node)
+		if node.ShortTag() == "!!null" {
+			values[name] = ""
+			continue
+		}
+		var value scalarText
+		if err := node.Decode(&value); err != nil {
+			return nil, fmt.Errorf("input %q must be a scalar value", name)
+		}
+		values[name] = string(value)
+	}
+	return values, nil
+}
+
 // hasWorkflowDispatch はトリガー設定に workflow_dispatch が含まれているか判定します
 func hasWorkflowDispatch(on any) bool {
 	switch v := on.(type) {
@@ -122,69 +270,258 @@ func hasWorkflowDispatch(on any) bool {
 	return false
 }
 
-// extractInputs は workflow_dispatch の inputs を抽出します
-func extractInputs(on any) map[string]Input {
-	m, ok := on.(map[string]any)
-	if !ok {
+// extractInputs はワークフローファイルから workflow_dispatch の inputs 定義を抽出します
+func extractInputs(content []byte) map[string]Input {
+	node := inputsNode(content)
+	if node == nil {
 		return nil
 	}
 
-	wd, ok := m["workflow_dispatch"]
-	if !ok {
+	// エイリアスやマージキー (<<) は Decode に解決させる
+	var raw map[string]yaml.Node
+	if err := node.Decode(&raw); err != nil {
 		return nil
 	}
 
-	wdMap, ok := wd.(map[string]any)
-	if !ok {
-		return nil
+	inputs := make(map[string]Input)
+	for key, val := range raw {
+		val = *resolveAlias(&val)
+		if val.Kind != yaml.MappingNode {
+			continue
+		}
+
+		// 型が合わないフィールドは無視し、読み込めたフィールドだけを使う
+		var def inputYAML
+		var typeErr *yaml.TypeError
+		if err := val.Decode(&def); err != nil && !errors.As(err, &typeErr) {
+			continue
+		}
+
+		input := Input{
+			Description: def.Description,
+			Required:    def.Required,
+			Default:     string(def.Default),
+			Type:        def.Type,
+		}
+		for _, opt := range def.Options {
+			input.Options = append(input.Options, string(opt))
+		}
+
+		inputs[key] = input
 	}
 
-	inputsRaw, ok := wdMap["inputs"]
-	if !ok {
+	if len(inputs) == 0 {
 		return nil
 	}
 
-	inputsMap, ok := inputsRaw.(map[string]any)
-	if !ok {
+	return inputs
+}
+
+// ValidateInput は1つの input の値を定義に照らして検証します
+func ValidateInput(input Input, value string) error {
+	if value == "" {
+		if input.Required {
+			return fmt.Errorf("required input is missing")
+		}
 		return nil
 	}
 
-	inputs := make(map[string]Input)
-	for key, val := range inputsMap {
-		inputMap, ok := val.(map[string]any)
-		if !ok {
-			continue
+	switch input.Type {
+	case InputTypeBoolean:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("must be true or false, got %q", value)
 		}
+	case InputTypeChoice:
+		if len(input.Options) > 0 && !slices.Contains(input.Options, value) {
+			return fmt.Errorf("must be one of [%s], got %q", strings.Join(input.Options, ", "), value)
+		}
+	case InputTypeEnvironment:
+		// Options は WithEnvironments でリポジトリの環境一覧が設定された場合のみ検証する
+		if len(input.Options) > 0 && !slices.Contains(input.Options, value) {
+			return fmt.Errorf("must be one of the environments [%s], got %q", strings.Join(input.Options, ", "), value)
+		}
+	case InputTypeNumber:
+		if !isNumber(value) {
+			return fmt.Errorf("must be a number, got %q", value)
+		}
+	}
 
-		input := Input{}
-		if desc, ok := inputMap["description"].(string); ok {
-			input.Description = desc
+	return nil
+}
+
+// numberPattern は number 型の input に指定できる10進数の表記です
+// strconv.ParseFloat と違い NaN, Inf, 1_000, 1e3 などは受け付けません
+var numberPattern = regexp.MustCompile(`^-?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+// isNumber は value が number 型の input の値として有効か判定します
+func isNumber(value string) bool {
+	return numberPattern.MatchString(value)
+}
+
+// ValidateInputs は inputs の値をワークフローの input 定義に照らして検証し、
+// 問題のあるすべての input を ValidationError として返します
+func ValidateInputs(schema map[string]Input, values map[string]string) error {
+	var errs []InputError
+
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := ValidateInput(schema[name], values[name]); err != nil {
+			errs = append(errs, InputError{Name: name, Message: err.Error()})
 		}
-		if req, ok := inputMap["required"].(bool); ok {
-			input.Required = req
+	}
+
+	// 定義されていない input は GitHub 側で拒否される
+	var unknown []string
+	for name := range values {
+		if _, ok := schema[name]; !ok {
+			unknown = append(unknown, name)
 		}
-		if def, ok := inputMap["default"].(string); ok {
-			input.Default = def
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, InputError{Name: name, Message: "not defined in the workflow"})
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// inputNames は inputs の名前を YAML に記述された順で返します
+// エイリアスやマージキー (<<) で定義されたものなど記述順を特定できない input は、名前順で末尾に加えます
+func inputNames(content []byte, inputs map[string]Input) []string {
+	var names []string
+	for _, name := range inputOrder(content) {
+		if _, ok := inputs[name]; ok && !slices.Contains(names, name) {
+			names = append(names, name)
 		}
-		if typ, ok := inputMap["type"].(string); ok {
-			input.Type = typ
+	}
+
+	var rest []string
+	for name := range inputs {
+		if !slices.Contains(names, name) {
+			rest = append(rest, name)
 		}
-		if opts, ok := inputMap["options"].([]any); ok {
-			for _, opt := range opts {
-				if optStr, ok := opt.(string); ok {
-					input.Options = append(input.Options, optStr)
-				}
-			}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+// inputOrder は workflow_dispatch の inputs 名を YAML に記述された順で返します
+func inputOrder(content []byte) []string {
+	node := inputsNode(content)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		// マージキーで取り込まれる input の順序は inputNames で補う
+		if node.Content[i].Tag == "!!merge" {
+			continue
 		}
+		names = append(names, node.Content[i].Value)
+	}
+	return names
+}
 
-		inputs[key] = input
+// inputsNode はワークフローファイルの on.workflow_dispatch.inputs のノードを返します
+func inputsNode(content []byte) *yaml.Node {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
 	}
 
-	if len(inputs) == 0 {
+	node := doc.Content[0]
+	for _, key := range []string{"on", "workflow_dispatch", "inputs"} {
+		node = mappingValue(node, key)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// mappingValue は YAML のマッピングノードから指定キーの値ノードを返します
+// エイリアスはその参照先のノードに解決します
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
 		return nil
 	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveAlias(node.Content[i+1])
+		}
+	}
+	return nil
+}
 
-	return inputs
+// resolveAlias はエイリアスノードをその参照先のノードに解決します
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// inputYAML は input 定義のパース用構造体です
+// default と options は GitHub に渡す形式の文字列として読み込みます
+type inputYAML struct {
+	Description string       `yaml:"description"`
+	Required    bool         `yaml:"required"`
+	Default     scalarText   `yaml:"default"`
+	Type        string       `yaml:"type"`
+	Options     []scalarText `yaml:"options"`
+}
+
+// scalarText は YAML のスカラー値を GitHub に渡す形式の文字列として読み込む型です
+// 真偽値は true/false に、10進数以外で書かれた数値は10進数に揃えます
+// それ以外は記述されたまま残し、日付が消えたり 1.10 が "1.1" になったりしないようにします
+// (例: True -> "true", 0o17 -> "15", 1.10 -> "1.10", 2024-01-01 -> "2024-01-01")
+type scalarText string
+
+func (s *scalarText) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: expected a scalar value", node.Line)}}
+	}
+	*s = scalarText(canonicalScalar(node))
+	return nil
+}
+
+// canonicalScalar はスカラーノードの値を YAML の型に応じた文字列に変換します
+func canonicalScalar(node *yaml.Node) string {
+	switch node.ShortTag() {
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err == nil {
+			return strconv.FormatBool(b)
+		}
+	case "!!int":
+		if isNumber(node.Value) {
+			return node.Value
+		}
+		var i int64
+		if err := node.Decode(&i); err == nil {
+			return strconv.FormatInt(i, 10)
+		}
+	case "!!float":
+		if isNumber(node.Value) {
+			return node.Value
+		}
+		// .nan や .inf は数値として扱えないため記述されたまま残す
+		var f float64
+		if err := node.Decode(&f); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	return node.Value
 }
 
 // createDispatchRequest はAPIエンドポイントとJSONペイロードを構築・検証します
@@ -198,6 +535,11 @@ func createDispatchRequest(params DispatchParams) (string, []byte, error) {
 	if params.Ref == "" {
 		return "", nil, fmt.Errorf("ref (branch) is required")
 	}
+	if params.Schema != nil {
+		if err := ValidateInputs(params.Schema, params.Inputs); err != nil {
+			return "", nil, err
+		}
+	}
 
 	endpoint := fmt.Sprintf("repos/%s/%s/actions/workflows/%s/dispatches",
 		params.Owner, params.Repo, params.WorkflowFile)
@@ -210,6 +552,10 @@ func createDispatchRequest(params DispatchParams) (string, []byte, error) {
 		payload["inputs"] = params.Inputs
 	}
 
+	if params.ReturnRunDetails {
+		payload["return_run_details"] = true
+	}
+
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to marshal payload: %w", err)
@@ -218,22 +564,48 @@ func createDispatchRequest(params DispatchParams) (string, []byte, error) {
 	return endpoint, body, nil
 }
 
+// DispatchRequest は RunDispatch が送信するリクエストのエンドポイントと JSON の本文を返します
+// dry run のように、実際には送信せずに内容を確認する場合に使います
+func DispatchRequest(params DispatchParams) (endpoint string, body []byte, err error) {
+	return createDispatchRequest(params)
+}
+
 // RunDispatch は指定されたパラメータでワークフローを実行します
-func RunDispatch(client RESTClient, params DispatchParams) error {
+// API が作成された実行の情報を返した場合はそれを返し、返さなかった場合は nil を返します
+// API がエラーを返した場合は *APIError を返します
+func RunDispatch(client RESTClient, params DispatchParams) (*Run, error) {
 	endpoint, body, err := createDispatchRequest(params)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	resp, err := client.Request(http.MethodPost, endpoint, bytes.NewBuffer(body))
 	if err != nil {
-		return fmt.Errorf("failed to dispatch request: %w", err)
+		if apiErr, ok := asAPIError(err); ok {
+			return nil, apiErr
+		}
+		return nil, fmt.Errorf("failed to dispatch request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// go-gh の RESTClient はエラーレスポンスを HTTPError として返すが、
+	// documentation_url を読めるようエラーレスポンスをそのまま返すクライアントにも対応する
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, parseAPIError(resp)
 	}
 
-	return nil
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	var details struct {
+		WorkflowRunID int64  `json:"workflow_run_id"`
+		HTMLURL       string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil || details.WorkflowRunID == 0 {
+		// 実行自体は成功しているため、実行の情報が読めなくてもエラーにはしない
+		return nil, nil
+	}
+
+	return &Run{ID: details.WorkflowRunID, HTMLURL: details.HTMLURL}, nil
 }
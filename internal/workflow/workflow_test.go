@@ -3,26 +3,49 @@ package workflow
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
 // mockRESTClient は workflow.RESTClient のモックです
 type mockRESTClient struct {
 	ResponseCode int
+	ResponseBody string
 	Error        error
+	RequestBody  []byte
 }
 
 func (m *mockRESTClient) Request(method string, path string, body io.Reader) (*http.Response, error) {
+	if body != nil {
+		m.RequestBody, _ = io.ReadAll(body)
+	}
 	if m.Error != nil {
 		return nil, m.Error
 	}
+	return newResponse(method, path, m.ResponseCode, nil, m.ResponseBody), nil
+}
+
+// newResponse は go-gh の http.Client が返すものと同じく Request を持つ JSON のレスポンスを作成します
+func newResponse(method, path string, code int, header http.Header, body string) *http.Response {
+	req, _ := http.NewRequest(method, "https://api.github.com/"+path, nil)
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json; charset=utf-8")
 	return &http.Response{
-		StatusCode: m.ResponseCode,
-		Body:       io.NopCloser(bytes.NewReader([]byte{})),
-	}, nil
+		StatusCode: code,
+		Status:     fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		Request:    req,
+	}
 }
 
 func TestRunDispatch(t *testing.T) {
@@ -30,7 +53,9 @@ func TestRunDispatch(t *testing.T) {
 		name          string
 		params        DispatchParams
 		mockCode      int
+		mockBody      string
 		mockError     error
+		wantRun       *Run
 		wantErrString string
 	}{
 		{
@@ -43,6 +68,19 @@ func TestRunDispatch(t *testing.T) {
 			},
 			mockCode: 204,
 		},
+		{
+			name: "success with run details",
+			params: DispatchParams{
+				Owner:            "user",
+				Repo:             "repo",
+				WorkflowFile:     "test.yml",
+				Ref:              "main",
+				ReturnRunDetails: true,
+			},
+			mockCode: 200,
+			mockBody: `{"workflow_run_id": 42, "run_url": "https://api.github.com/repos/user/repo/actions/runs/42", "html_url": "https://github.com/user/repo/actions/runs/42"}`,
+			wantRun:  &Run{ID: 42, HTMLURL: "https://github.com/user/repo/actions/runs/42"},
+		},
 		{
 			name: "create request error (missing param)",
 			params: DispatchParams{
@@ -78,10 +116,11 @@ func TestRunDispatch(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			client := &mockRESTClient{
 				ResponseCode: tt.mockCode,
+				ResponseBody: tt.mockBody,
 				Error:        tt.mockError,
 			}
 
-			err := RunDispatch(client, tt.params)
+			run, err := RunDispatch(client, tt.params)
 
 			if tt.wantErrString != "" {
 				if err == nil {
@@ -95,6 +134,9 @@ func TestRunDispatch(t *testing.T) {
 			if err != nil {
 				t.Errorf("RunDispatch() unexpected error: %v", err)
 			}
+			if !reflect.DeepEqual(run, tt.wantRun) {
+				t.Errorf("RunDispatch() run = %+v, want %+v", run, tt.wantRun)
+			}
 		})
 	}
 }
@@ -146,6 +188,21 @@ func TestCreateDispatchRequest(t *testing.T) {
 			},
 			wantErrString: "ref (branch) is required",
 		},
+		{
+			name: "inputs violate schema",
+			params: DispatchParams{
+				Owner:        "user",
+				Repo:         "repo",
+				WorkflowFile: "deploy.yml",
+				Ref:          "main",
+				Inputs:       map[string]string{"dry_run": "yes"},
+				Schema: map[string]Input{
+					"environment": {Required: true},
+					"dry_run":     {Type: InputTypeBoolean},
+				},
+			},
+			wantErrString: "invalid inputs:\n  - dry_run: must be true or false, got \"yes\"\n  - environment: required input is missing",
+		},
 	}
 
 	for _, tt := range tests {
@@ -234,3 +291,432 @@ func TestHasWorkflowDispatch(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateInputs(t *testing.T) {
+	schema := map[string]Input{
+		"environment": {Type: InputTypeChoice, Required: true, Options: []string{"staging", "production"}},
+		"dry_run":     {Type: InputTypeBoolean},
+		"replicas":    {Type: InputTypeNumber},
+		"version":     {Type: InputTypeString},
+		"target":      {Type: InputTypeEnvironment, Options: []string{"prod"}},
+		"free_target": {Type: InputTypeEnvironment},
+	}
+
+	tests := []struct {
+		name       string
+		values     map[string]string
+		wantErrors []InputError
+	}{
+		{
+			name: "valid",
+			values: map[string]string{
+				"environment": "staging",
+				"dry_run":     "true",
+				"replicas":    "3",
+				"version":     "v1.2.3",
+			},
+		},
+		{
+			name:   "optional inputs may be empty",
+			values: map[string]string{"environment": "production"},
+		},
+		{
+			name: "every offending input is reported",
+			values: map[string]string{
+				"dry_run":  "yes",
+				"replicas": "three",
+				"unknown":  "x",
+			},
+			wantErrors: []InputError{
+				{Name: "dry_run", Message: `must be true or false, got "yes"`},
+				{Name: "environment", Message: "required input is missing"},
+				{Name: "replicas", Message: `must be a number, got "three"`},
+				{Name: "unknown", Message: "not defined in the workflow"},
+			},
+		},
+		{
+			name:   "decimal numbers",
+			values: map[string]string{"environment": "staging", "replicas": "-1.5"},
+		},
+		{
+			name:   "NaN is not a number",
+			values: map[string]string{"environment": "staging", "replicas": "NaN"},
+			wantErrors: []InputError{
+				{Name: "replicas", Message: `must be a number, got "NaN"`},
+			},
+		},
+		{
+			name:   "Inf is not a number",
+			values: map[string]string{"environment": "staging", "replicas": "-inf"},
+			wantErrors: []InputError{
+				{Name: "replicas", Message: `must be a number, got "-inf"`},
+			},
+		},
+		{
+			name:   "digit separators are not accepted",
+			values: map[string]string{"environment": "staging", "replicas": "1_000"},
+			wantErrors: []InputError{
+				{Name: "replicas", Message: `must be a number, got "1_000"`},
+			},
+		},
+		{
+			name:   "environment in list",
+			values: map[string]string{"environment": "staging", "target": "prod", "free_target": "anything"},
+		},
+		{
+			name:   "environment not in list",
+			values: map[string]string{"environment": "staging", "target": "dev"},
+			wantErrors: []InputError{
+				{Name: "target", Message: `must be one of the environments [prod], got "dev"`},
+			},
+		},
+		{
+			name:   "choice not in options",
+			values: map[string]string{"environment": "dev"},
+			wantErrors: []InputError{
+				{Name: "environment", Message: `must be one of [staging, production], got "dev"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateInputs(schema, tt.values)
+
+			if tt.wantErrors == nil {
+				if err != nil {
+					t.Errorf("ValidateInputs() unexpected error: %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("ValidateInputs() error = %v, want *ValidationError", err)
+			}
+			if !reflect.DeepEqual(verr.Errors, tt.wantErrors) {
+				t.Errorf("ValidateInputs() errors = %v, want %v", verr.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestExtractInputs(t *testing.T) {
+	content := []byte(`
+on:
+  workflow_dispatch:
+    inputs:
+      environment:
+        description: Environment
+        required: true
+        type: choice
+        default: staging
+        options: [staging, production]
+      dry_run:
+        type: boolean
+        default: true
+      replicas:
+        type: number
+        default: 3
+      ratio:
+        type: number
+        default: 0.5
+        options: [0.5, 1, 2]
+      note:
+        type: string
+      release_date:
+        default: 2024-01-01
+        type: choice
+        options: [2024-01-01, 2024-02-01]
+      version:
+        type: choice
+        default: 1.0
+        options: [1.0, 1.10]
+      notify:
+        type: boolean
+        default: True
+      mode:
+        type: number
+        default: 0o17
+      limit:
+        type: number
+        default: 0x1F
+        options: [1e3, +2.5, .inf]
+`)
+
+	want := map[string]Input{
+		"environment": {Description: "Environment", Required: true, Type: "choice", Default: "staging", Options: []string{"staging", "production"}},
+		"dry_run":     {Type: "boolean", Default: "true"},
+		"replicas":    {Type: "number", Default: "3"},
+		"ratio":       {Type: "number", Default: "0.5", Options: []string{"0.5", "1", "2"}},
+		"note":        {Type: "string"},
+		// 日付や末尾の 0 は型付きの値に変換せず、記述されたまま残す
+		"release_date": {Type: "choice", Default: "2024-01-01", Options: []string{"2024-01-01", "2024-02-01"}},
+		"version":      {Type: "choice", Default: "1.0", Options: []string{"1.0", "1.10"}},
+		// 真偽値と10進数以外で書かれた数値は GitHub に渡す形式に揃える
+		"notify": {Type: "boolean", Default: "true"},
+		"mode":   {Type: "number", Default: "15"},
+		"limit":  {Type: "number", Default: "31", Options: []string{"1000", "2.5", ".inf"}},
+	}
+
+	if got := extractInputs(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("extractInputs() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadDispatchableWorkflows(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"deploy.yml": `
+name: Deploy
+on:
+  workflow_dispatch:
+    inputs:
+      version:
+        type: string
+      environment:
+        type: choice
+        options: [staging, production]
+      dry_run:
+        type: boolean
+`,
+		"ci.yaml": `
+on: [push, workflow_dispatch]
+`,
+		"push.yml": `
+on: push
+`,
+		"README.md": "not a workflow",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := LoadDispatchableWorkflows(dir)
+	if err != nil {
+		t.Fatalf("LoadDispatchableWorkflows() unexpected error: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("LoadDispatchableWorkflows() returned %d workflows, want 2", len(got))
+	}
+
+	if got[0].FileName != "ci.yaml" || got[0].Name != "ci.yaml" || got[0].InputNames != nil {
+		t.Errorf("LoadDispatchableWorkflows()[0] = %+v, want ci.yaml without inputs", got[0])
+	}
+
+	wantNames := []string{"version", "environment", "dry_run"}
+	if got[1].Name != "Deploy" || !reflect.DeepEqual(got[1].InputNames, wantNames) {
+		t.Errorf("LoadDispatchableWorkflows()[1] name = %q, input names = %v, want %q, %v", got[1].Name, got[1].InputNames, "Deploy", wantNames)
+	}
+}
+
+func TestInputNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "declaration order",
+			content: `
+on:
+  workflow_dispatch:
+    inputs:
+      version: {}
+      environment: {}
+`,
+			want: []string{"version", "environment"},
+		},
+		{
+			name: "anchored inputs block",
+			content: `
+x-common: &common
+  version: {}
+  environment: {}
+on:
+  workflow_dispatch:
+    inputs: *common
+`,
+			want: []string{"version", "environment"},
+		},
+		{
+			name: "merge key",
+			content: `
+x-env: &env
+  environment:
+    type: environment
+  dry_run:
+    type: boolean
+on:
+  workflow_dispatch:
+    inputs:
+      version: {}
+      <<: *env
+`,
+			want: []string{"version", "dry_run", "environment"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := []byte(tt.content)
+			inputs := extractInputs(content)
+			if len(inputs) != len(tt.want) {
+				t.Fatalf("extractInputs() = %v, want inputs %v", inputs, tt.want)
+			}
+			if got := inputNames(content, inputs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inputNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindWorkflow(t *testing.T) {
+	workflows := []Workflow{
+		{Name: "Deploy", Path: ".github/workflows/deploy.yml", FileName: "deploy.yml"},
+		{Name: "Release", Path: ".github/workflows/release.yaml", FileName: "release.yaml"},
+		{Name: "Release", Path: ".github/workflows/release-legacy.yml", FileName: "release-legacy.yml"},
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		wantFile      string
+		wantErrString string
+	}{
+		{name: "file name", query: "deploy.yml", wantFile: "deploy.yml"},
+		{name: "path", query: ".github/workflows/release.yaml", wantFile: "release.yaml"},
+		{name: "file name without extension", query: "release-legacy", wantFile: "release-legacy.yml"},
+		{name: "display name", query: "deploy", wantFile: "deploy.yml"},
+		{
+			name:          "ambiguous display name",
+			query:         "Release",
+			wantErrString: `workflow name "Release" is ambiguous, specify one of: release.yaml, release-legacy.yml`,
+		},
+		{name: "not found", query: "lint", wantErrString: `workflow "lint" not found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindWorkflow(workflows, tt.query)
+
+			if tt.wantErrString != "" {
+				if err == nil || err.Error() != tt.wantErrString {
+					t.Errorf("FindWorkflow() error = %v, want %v", err, tt.wantErrString)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("FindWorkflow() unexpected error: %v", err)
+			}
+			if got.FileName != tt.wantFile {
+				t.Errorf("FindWorkflow() = %v, want %v", got.FileName, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestWithEnvironments(t *testing.T) {
+	schema := map[string]Input{
+		"target":  {Type: InputTypeEnvironment, Required: true},
+		"version": {Type: InputTypeString},
+	}
+
+	got := WithEnvironments(schema, []string{"staging", "production"})
+	want := map[string]Input{
+		"target":  {Type: InputTypeEnvironment, Required: true, Options: []string{"staging", "production"}},
+		"version": {Type: InputTypeString},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WithEnvironments() = %v, want %v", got, want)
+	}
+	if schema["target"].Options != nil {
+		t.Errorf("WithEnvironments() modified the original schema: %v", schema)
+	}
+
+	if got := WithEnvironments(schema, nil); !reflect.DeepEqual(got, schema) {
+		t.Errorf("WithEnvironments() without environments = %v, want %v", got, schema)
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	schema := map[string]Input{
+		"environment": {Default: "staging"},
+		"version":     {Default: "latest"},
+		"note":        {},
+	}
+	values := map[string]string{"version": "v1.0.0"}
+
+	got := ApplyDefaults(schema, values)
+	want := map[string]string{"environment": "staging", "version": "v1.0.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplyDefaults() = %v, want %v", got, want)
+	}
+	if len(values) != 1 {
+		t.Errorf("ApplyDefaults() modified its argument: %v", values)
+	}
+}
+
+func TestParseInputValues(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		want          map[string]string
+		wantErrString string
+	}{
+		{
+			name:    "json",
+			content: `{"environment": "staging", "dry_run": true, "replicas": 3}`,
+			want:    map[string]string{"environment": "staging", "dry_run": "true", "replicas": "3"},
+		},
+		{
+			name:    "yaml",
+			content: "environment: production\nratio: 0.5\nnote:\n",
+			want:    map[string]string{"environment": "production", "ratio": "0.5", "note": ""},
+		},
+		{
+			name:    "values are kept as written",
+			content: "version: 1.10\nrelease_date: 2024-01-01\nnote: ~\n",
+			want:    map[string]string{"version": "1.10", "release_date": "2024-01-01", "note": ""},
+		},
+		{
+			name:    "booleans and numbers are normalized",
+			content: "dry_run: True\nmode: 0o17\n",
+			want:    map[string]string{"dry_run": "true", "mode": "15"},
+		},
+		{
+			name:    "empty",
+			content: "",
+			want:    map[string]string{},
+		},
+		{
+			name:          "nested value",
+			content:       "environment:\n  name: staging\n",
+			wantErrString: `input "environment" must be a scalar value`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInputValues(strings.NewReader(tt.content))
+
+			if tt.wantErrString != "" {
+				if err == nil || err.Error() != tt.wantErrString {
+					t.Errorf("ParseInputValues() error = %v, want %v", err, tt.wantErrString)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseInputValues() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseInputValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
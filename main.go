@@ -1,19 +1,36 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/cli/go-gh/v2/pkg/api"
-	"github.com/cli/go-gh/v2/pkg/repository"
 	"github.com/yanskun/gh-dispatch/internal/branch"
+	"github.com/yanskun/gh-dispatch/internal/environment"
+	"github.com/yanskun/gh-dispatch/internal/gitsync"
+	"github.com/yanskun/gh-dispatch/internal/history"
+	"github.com/yanskun/gh-dispatch/internal/preset"
+	"github.com/yanskun/gh-dispatch/internal/rest"
+	"github.com/yanskun/gh-dispatch/internal/retry"
 	"github.com/yanskun/gh-dispatch/internal/workflow"
 )
 
@@ -41,26 +58,41 @@ var (
 			Foreground(lipgloss.Color("203")).
 			Bold(true)
 
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("203"))
+
 	hintStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
 			Italic(true).
 			MarginTop(1)
+
+	placeholderStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("241")).
+				Italic(true)
 )
 
 type state int
 
 const (
 	selectingWorkflow state = iota
+	selectingPreset
 	selectingBranch
+	enteringRef
 	enteringInputs
+	fillingForm // すべての inputs を 1 画面で入力するフォーム
 	confirming
+	savingPreset
 	executing
+	watching
 )
 
 type item struct {
 	title, desc string
 	fileName    string                    // 実行時にファイル名が必要
 	inputs      map[string]workflow.Input // workflow_dispatch の inputs
+	inputNames  []string                  // inputs の記述順
+	preset      *preset.Preset            // プリセット選択画面の項目 (nil は手動入力)
+	refKind     string                    // ref の種類 (branch.KindBranch / branch.KindTag)
 }
 
 func (i item) Title() string       { return i.title }
@@ -72,7 +104,17 @@ type model struct {
 	list             list.Model
 	state            state
 	workflows        []list.Item
-	branches         []list.Item
+	branches         []branch.Branch  // ブランチ一覧 (API の順 = 名前順)
+	sortByRecent     bool             // ブランチを最新コミットの新しい順に並べるか
+	branchesLoading  bool             // ブランチ一覧をバックグラウンドで取得中か
+	branchesErr      error            // ブランチ一覧の取得エラー
+	searcher         *branch.Searcher // ブランチ数が多い場合のサーバー側検索 (nil なら全件取得済み)
+	searchQuery      string           // 最後に検索を予約したフィルタ文字列
+	searchSeq        int              // デバウンス中の検索を識別する連番
+	gqlClient        *retry.GraphQL
+	spinner          spinner.Model
+	environments     []environment.Environment // type: environment の input の選択肢
+	environmentsErr  error                     // 環境一覧の取得エラー
 	selectedWorkflow item
 	selectedBranch   item
 	quitting         bool
@@ -83,105 +125,497 @@ type model struct {
 	userInputs       map[string]string
 	inputKeys        []string
 	currentInputIdx  int
-	inputBuffer      string
+	editingInput     bool              // 確認画面から 1 つの input を編集中か
+	formFields       []formField       // フォーム画面の入力欄
+	formIdx          int               // フォーム画面でフォーカス中の入力欄
+	formThreshold    int               // inputs がこの数より多い場合にフォーム画面を使う
+	confirmIdx       int               // 確認画面で選択中の input の位置
+	field            formField         // 1 つずつ入力する画面で入力中の input の入力欄
+	prefilledInputs  map[string]string // --inputs-file で与えられた値
+	ignoredInputs    []string          // ワークフローに定義されていない prefilled の input
+	rootPath         string            // リポジトリのルートパス
+	presets          []preset.Preset   // リポジトリ単位・ユーザー単位のプリセット
+	presetName       string            // --preset で指定されたプリセット名
+	presetInputs     map[string]string // 選択されたプリセットの値
+	presetNameInput  textinput.Model   // 保存するプリセット名の入力欄
+	presetUserScope  bool              // プリセットをユーザー単位のファイルに保存するか
+	statusMsg        string            // 確認画面に表示するメッセージ
+	syncStatus       *gitsync.Status   // 選択したブランチのローカルとリモートの差分 (ローカルにない場合は nil)
+	watch            bool              // 実行後にジョブの状態を監視するか
+	dryRun           bool              // dispatch せずにリクエストの内容を表示するか
+	showRequest      bool              // 確認画面に送信するリクエストを表示するか
+	showCommands     bool              // 確認画面に同じ dispatch を再現するコマンドを表示するか
+	host             string            // 対象リポジトリのホスト
+	client           *retry.Client
+	refKind          string      // ref 選択画面に表示中の種類
+	tags             []list.Item // 取得済みのタグ一覧
+	tagsLoaded       bool
+	tagsLoading      bool            // タグ一覧を取得中か
+	tagsErr          error           // タグ一覧の取得エラー
+	refInput         textinput.Model // 直接入力中の ref
+	refErr           string          // 直接入力された ref の確認エラー
+	resolvingRef     bool            // 直接入力された ref を API で確認中か
+	run              *workflow.Run   // 監視中の実行
+	jobs             []workflow.Job  // 監視中の実行のジョブ
+	watchErr         string          // 監視中のエラー
+	now              time.Time       // 経過時間の表示に使う現在時刻
+}
+
+// branchesLoadedMsg はブランチ一覧の取得結果です
+type branchesLoadedMsg struct {
+	branches []branch.Branch
+	searcher *branch.Searcher
+	err      error
+}
+
+// loadBranches はブランチ一覧を取得するコマンドを返します
+// ブランチ数が branch.SearchThreshold を超える場合はサーバー側検索に切り替えます
+// GraphQL が使えない場合は REST で名前だけ取得します
+func loadBranches(client *retry.Client, gqlClient *retry.GraphQL, owner, repo string) tea.Cmd {
+	return func() tea.Msg {
+		branches, searcher, err := branch.LoadBranches(gqlClient, owner, repo, branch.SearchThreshold)
+		if err != nil {
+			branches, err = branch.FetchBranches(client, owner, repo)
+		}
+		return branchesLoadedMsg{branches: branches, searcher: searcher, err: err}
+	}
+}
+
+// searchDebounce はフィルタ入力が止まってからサーバー側検索を行うまでの待ち時間です
+const searchDebounce = 300 * time.Millisecond
+
+// branchSearchMsg はデバウンス後に検索を開始する合図です
+type branchSearchMsg struct {
+	seq   int
+	query string
+}
+
+// branchSearchResultMsg はサーバー側検索の結果です
+type branchSearchResultMsg struct {
+	query    string
+	branches []branch.Branch
+	err      error
+}
+
+// searchBranches はブランチをサーバー側で検索するコマンドを返します
+func searchBranches(searcher *branch.Searcher, query string) tea.Cmd {
+	return func() tea.Msg {
+		branches, err := searcher.Search(query)
+		return branchSearchResultMsg{query: query, branches: branches, err: err}
+	}
+}
+
+// pushedMsg は確認画面から実行した git push の結果です
+type pushedMsg struct {
+	err error
+}
+
+// tagsLoadedMsg はタグ一覧の取得結果です
+type tagsLoadedMsg struct {
+	items []list.Item
+	err   error
+}
+
+// refResolvedMsg は直接入力された ref の確認結果です
+type refResolvedMsg struct {
+	name, kind string
+	err        error
+}
+
+// fetchTags はタグ一覧を取得するコマンドを返します
+func fetchTags(client *retry.Client, owner, repo string) tea.Cmd {
+	return func() tea.Msg {
+		tags, err := branch.FetchTags(client, owner, repo)
+		if err != nil {
+			return tagsLoadedMsg{err: err}
+		}
+
+		items := []list.Item{}
+		for _, t := range tags {
+			items = append(items, item{title: t.Name, desc: "Tag", refKind: branch.KindTag})
+		}
+		return tagsLoadedMsg{items: items}
+	}
+}
+
+// resolveRef は直接入力された ref がブランチまたはタグとして存在するか確認するコマンドを返します
+func resolveRef(client *retry.Client, owner, repo, ref string) tea.Cmd {
+	return func() tea.Msg {
+		name, kind, err := branch.ResolveRef(client, owner, repo, ref)
+		return refResolvedMsg{name: name, kind: kind, err: err}
+	}
 }
 
-func (m model) Init() tea.Cmd { return nil }
+func (m model) Init() tea.Cmd {
+	if m.state == watching {
+		return tea.Batch(pollRun(m.client, m.owner, m.repo, m.run.ID), tick())
+	}
+	var cmds []tea.Cmd
+	if m.branchesLoading {
+		cmds = append(cmds, m.spinner.Tick, loadBranches(m.client, m.gqlClient, m.owner, m.repo))
+	}
+	if needsEnvironments(m.workflows) {
+		cmds = append(cmds, loadEnvironments(m.client, m.owner, m.repo))
+	}
+	return tea.Batch(cmds...)
+}
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.state == watching {
+		return m.updateWatching(msg)
+	}
+
 	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		if !m.branchesLoading {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case branchesLoadedMsg:
+		m.branchesLoading = false
+		m.branchesErr = msg.err
+		if msg.err == nil {
+			m.branches = msg.branches
+			m.searcher = msg.searcher
+		}
+		if m.state == selectingBranch && m.refKind == branch.KindBranch {
+			return m, m.showBranches()
+		}
+		return m, nil
+	case environmentsLoadedMsg:
+		m.environmentsErr = msg.err
+		if msg.err == nil {
+			m.environments = msg.environments
+			m.applyEnvironments()
+		}
+		return m, nil
+	case branchSearchMsg:
+		// 入力が続いている間に予約された古い検索は捨てる
+		if msg.seq != m.searchSeq || m.searcher == nil {
+			return m, nil
+		}
+		return m, tea.Batch(
+			m.list.NewStatusMessage(hintStyle.Render("Searching branches...")),
+			searchBranches(m.searcher, msg.query),
+		)
+	case branchSearchResultMsg:
+		if msg.query != m.searchQuery {
+			return m, nil
+		}
+		if msg.err != nil {
+			return m, m.list.NewStatusMessage(errorStyle.Render(msg.err.Error()))
+		}
+		m.branches = msg.branches
+		if m.state != selectingBranch || m.refKind != branch.KindBranch {
+			return m, nil
+		}
+		// フィルタ中であれば SetItems が検索結果に対して再度フィルタをかける
+		return m, m.list.SetItems(branchItems(m.branches, m.sortByRecent, time.Now()))
+	case pushedMsg:
+		if msg.err != nil {
+			m.statusMsg = "✗ Failed to push: " + msg.err.Error()
+		} else {
+			m.statusMsg = "✓ Pushed " + m.syncStatus.Branch + " to " + m.syncStatus.Remote
+		}
+		m.checkSync()
+		return m, nil
+	case tagsLoadedMsg:
+		m.tagsLoading = false
+		if msg.err != nil {
+			m.tagsErr = msg.err
+			if m.state == selectingBranch && m.refKind == branch.KindTag {
+				m.list.Title = "Select a Tag"
+			}
+			return m, nil
+		}
+		m.tags = msg.items
+		m.tagsLoaded = true
+		if m.state == selectingBranch && m.refKind == branch.KindTag {
+			return m, m.showTags()
+		}
+		return m, nil
+	case refResolvedMsg:
+		m.resolvingRef = false
+		if m.state != enteringRef {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.refErr = msg.err.Error()
+			return m, nil
+		}
+		m.selectedBranch = item{title: msg.name, refKind: msg.kind}
+		m.startInputs()
+		return m, nil
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" {
 			m.quitting = true
 			return m, tea.Quit
 		}
 
+		// ブランチ一覧の取得に失敗した場合は Enter で再試行する
+		if m.state == selectingBranch && m.refKind == branch.KindBranch && m.branchesErr != nil && msg.String() == "enter" {
+			m.branchesLoading = true
+			m.branchesErr = nil
+			return m, tea.Batch(m.spinner.Tick, loadBranches(m.client, m.gqlClient, m.owner, m.repo))
+		}
+
+		// タグ一覧の取得に失敗した場合も Enter で再試行する
+		if m.state == selectingBranch && m.refKind == branch.KindTag && m.tagsErr != nil && msg.String() == "enter" {
+			m.tagsErr = nil
+			return m, m.showTags()
+		}
+
+		// 絞り込みをしていない選択画面では Esc で前の画面へ戻る (ワークフロー一覧では終了する)
+		if msg.String() == "esc" && m.list.FilterState() == list.Unfiltered {
+			switch m.state {
+			case selectingPreset:
+				return m, m.showWorkflows()
+			case selectingBranch:
+				if m.presetsForSelected() != nil {
+					return m, m.showPresets(m.presetsForSelected())
+				}
+				return m, m.showWorkflows()
+			}
+		}
+
+		// ref 選択画面でのキー操作 (フィルタ入力中は除く)
+		if m.state == selectingBranch && m.list.FilterState() != list.Filtering {
+			switch msg.String() {
+			case "tab":
+				if m.refKind == branch.KindTag {
+					return m, m.showBranches()
+				}
+				return m, m.showTags()
+			case "s":
+				if m.refKind == branch.KindBranch {
+					m.sortByRecent = !m.sortByRecent
+					return m, m.showBranches()
+				}
+				return m, nil
+			case "r":
+				m.state = enteringRef
+				m.refInput = newTextInput("e.g. main, v1.2.0, refs/heads/feature")
+				m.refErr = ""
+				return m, nil
+			}
+		}
+
+		// ref 直接入力画面でのキー操作
+		if m.state == enteringRef {
+			if m.resolvingRef {
+				return m, nil
+			}
+			switch msg.String() {
+			case "enter":
+				ref := strings.TrimSpace(m.refInput.Value())
+				if ref == "" {
+					return m, nil
+				}
+				m.resolvingRef = true
+				m.refErr = ""
+				return m, resolveRef(m.client, m.owner, m.repo, ref)
+			case "esc":
+				m.state = selectingBranch
+			default:
+				var cmd tea.Cmd
+				m.refErr = ""
+				m.refInput, cmd = m.refInput.Update(msg)
+				return m, cmd
+			}
+			return m, nil
+		}
+
 		// 確認画面でのキー操作
 		if m.state == confirming {
 			switch msg.String() {
 			case "y", "Y":
 				m.state = executing
 				return m, tea.Quit
-			case "n", "N", "esc":
+			case "n", "N":
 				m.quitting = true
 				return m, tea.Quit
+			case "esc":
+				// inputs があれば最後の input、なければ ref の選択へ戻る
+				m.statusMsg = ""
+				if len(m.inputKeys) > 0 && m.formMode() {
+					m.startForm(len(m.inputKeys) - 1)
+					return m, nil
+				}
+				if len(m.inputKeys) > 0 {
+					m.state = enteringInputs
+					m.currentInputIdx = len(m.inputKeys) - 1
+					m.resetInput()
+					return m, nil
+				}
+				return m, m.showRefPicker()
+			case "up", "k":
+				if m.confirmIdx > 0 {
+					m.confirmIdx--
+				}
+				return m, nil
+			case "down", "j":
+				if m.confirmIdx < len(m.inputKeys)-1 {
+					m.confirmIdx++
+				}
+				return m, nil
+			case "e", "enter":
+				// フォーム画面では選択中の input にフォーカスしてフォームを開く
+				if len(m.inputKeys) > 0 && m.formMode() {
+					m.statusMsg = ""
+					m.startForm(m.confirmIdx)
+					return m, nil
+				}
+				// 選択中の input だけを編集して確認画面へ戻る
+				if len(m.inputKeys) > 0 {
+					m.state = enteringInputs
+					m.editingInput = true
+					m.currentInputIdx = m.confirmIdx
+					m.statusMsg = ""
+					m.resetInput()
+				}
+				return m, nil
+			case "s":
+				if len(m.userInputs) > 0 {
+					m.state = savingPreset
+					m.presetNameInput = newTextInput("e.g. staging-deploy")
+					m.statusMsg = ""
+				}
+				return m, nil
+			case "d":
+				m.showRequest = !m.showRequest
+				return m, nil
+			case "c":
+				// クリップボードが使えない環境でもコピーできるよう、コマンドは画面にも表示する
+				m.showCommands = true
+				dispatchCmd, workflowCmd := m.reproduceCommands()
+				if err := clipboard.WriteAll(dispatchCmd + "\n" + workflowCmd + "\n"); err != nil {
+					m.statusMsg = "Clipboard is not available, copy the commands above"
+				} else {
+					m.statusMsg = "✓ Copied the commands to the clipboard"
+				}
+				return m, nil
+			case "p":
+				// TUI を一時停止して git push を実行する (認証の入力に端末を使えるようにする)
+				if m.syncStatus != nil && m.syncStatus.CanPush() {
+					m.statusMsg = ""
+					return m, tea.ExecProcess(gitsync.PushCommand(m.rootPath, *m.syncStatus), func(err error) tea.Msg {
+						return pushedMsg{err: err}
+					})
+				}
+				return m, nil
 			default:
 				return m, nil
 			}
 		}
 
-		if msg.String() == "enter" {
+		// プリセット保存画面でのキー操作
+		if m.state == savingPreset {
+			switch msg.String() {
+			case "enter":
+				if strings.TrimSpace(m.presetNameInput.Value()) == "" {
+					return m, nil
+				}
+				m.statusMsg = m.savePreset()
+				m.state = confirming
+			case "esc":
+				m.state = confirming
+			case "tab":
+				m.presetUserScope = !m.presetUserScope
+			default:
+				var cmd tea.Cmd
+				m.presetNameInput, cmd = m.presetNameInput.Update(msg)
+				return m, cmd
+			}
+			return m, nil
+		}
+
+		if msg.String() == "enter" && (m.state == selectingWorkflow || m.state == selectingPreset || m.state == selectingBranch) {
 			i, ok := m.list.SelectedItem().(item)
 			if !ok {
 				return m, nil
 			}
 
 			if m.state == selectingWorkflow {
+				// 別のワークフローを選び直した場合は入力済みの値を破棄する
+				if i.fileName != m.selectedWorkflow.fileName {
+					m.userInputs = nil
+				}
 				m.selectedWorkflow = i
-				m.state = selectingBranch
-				m.list.Title = fmt.Sprintf("Select a Branch (Current: %s)", m.currentBranch)
-				m.list.ResetSelected()
-				m.list.ResetFilter()
-
-				// カレントブランチをデフォルト選択にする
-				newItems := m.branches
-				cmd := m.list.SetItems(newItems)
-
-				for idx, it := range newItems {
-					if it.(item).title == m.currentBranch {
-						m.list.Select(idx)
-						break
+				m.presetInputs = nil
+
+				// プリセットがある場合はブランチ選択の前に選ばせる
+				presets := m.presetsForSelected()
+				var notFound error
+				if m.presetName != "" {
+					p, err := preset.Find(presets, m.presetName)
+					if err == nil {
+						m.presetInputs = p.Inputs
+						return m, m.showBranches()
 					}
+					notFound = fmt.Errorf("%w for %s", err, m.selectedWorkflow.title)
+				}
+				var cmd tea.Cmd
+				if len(presets) > 0 {
+					cmd = m.showPresets(presets)
+				} else {
+					cmd = m.showBranches()
+				}
+				// --preset の指定ミスに気付けるよう、一覧の上にエラーを表示する
+				if notFound != nil {
+					cmd = tea.Batch(cmd, m.listError(notFound.Error()))
 				}
-
 				return m, cmd
+			} else if m.state == selectingPreset {
+				if i.preset != nil {
+					m.presetInputs = i.preset.Inputs
+				}
+				return m, m.showBranches()
 			} else if m.state == selectingBranch {
 				m.selectedBranch = i
-				// inputs がある場合は入力画面へ、ない場合は確認画面へ
-				if len(m.selectedWorkflow.inputs) > 0 {
-					m.state = enteringInputs
-					m.workflowInputs = m.selectedWorkflow.inputs
-					m.userInputs = make(map[string]string)
-					m.inputKeys = []string{}
-					for key := range m.workflowInputs {
-						m.inputKeys = append(m.inputKeys, key)
-					}
-					m.currentInputIdx = 0
-					m.inputBuffer = ""
-				} else {
-					m.state = confirming
-				}
+				m.startInputs()
 				return m, nil
 			}
 		}
 
+		// フォーム画面でのキー操作
+		if m.state == fillingForm {
+			return m, m.updateForm(msg)
+		}
+
 		// inputs 入力中の処理
 		if m.state == enteringInputs {
 			if msg.String() == "enter" {
+				// 不正な値の場合は同じ input を再入力させる
+				if !m.field.validate() {
+					return m, nil
+				}
+
 				// 現在の入力を保存
-				key := m.inputKeys[m.currentInputIdx]
-				if m.inputBuffer == "" && m.workflowInputs[key].Default != "" {
-					m.userInputs[key] = m.workflowInputs[key].Default
-				} else {
-					m.userInputs[key] = m.inputBuffer
+				m.userInputs[m.field.key] = m.field.value()
+
+				// 確認画面から編集した場合はすぐに確認画面へ戻る
+				if m.editingInput {
+					m.editingInput = false
+					m.state = confirming
+					return m, nil
 				}
-				m.inputBuffer = ""
 
 				// 次の入力へ
 				m.currentInputIdx++
 				if m.currentInputIdx >= len(m.inputKeys) {
 					m.state = confirming
+				} else {
+					m.resetInput()
 				}
 				return m, nil
-			} else if msg.String() == "backspace" {
-				if len(m.inputBuffer) > 0 {
-					m.inputBuffer = m.inputBuffer[:len(m.inputBuffer)-1]
-				}
-				return m, nil
-			} else if len(msg.String()) == 1 {
-				m.inputBuffer += msg.String()
-				return m, nil
 			}
+			if msg.String() == "esc" {
+				return m, m.stepBack()
+			}
+
+			return m, m.field.update(msg)
 		}
 	case tea.WindowSizeMsg:
 		h, v := docStyle.GetFrameSize()
@@ -189,13 +623,415 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 	var cmd tea.Cmd
 	// リスト操作は選択画面のみ有効
-	if m.state == selectingWorkflow || m.state == selectingBranch {
+	if m.state == selectingWorkflow || m.state == selectingPreset || m.state == selectingBranch {
 		m.list, cmd = m.list.Update(msg)
 	}
+	// Ctrl+V による貼り付けの結果など、キー以外のメッセージも入力欄へ渡す
+	switch m.state {
+	case enteringInputs:
+		cmd = m.field.update(msg)
+	case fillingForm:
+		cmd = m.formFields[m.formIdx].update(msg)
+	case enteringRef:
+		m.refInput, cmd = m.refInput.Update(msg)
+	case savingPreset:
+		m.presetNameInput, cmd = m.presetNameInput.Update(msg)
+	}
+	if m.state == selectingBranch && m.refKind == branch.KindBranch && m.searcher != nil {
+		cmd = tea.Batch(cmd, m.scheduleSearch())
+	}
 	return m, cmd
 }
 
+// scheduleSearch はフィルタ文字列が変わった場合に、デバウンス後のサーバー側検索を予約します
+func (m *model) scheduleSearch() tea.Cmd {
+	query := m.list.FilterValue()
+	if query == m.searchQuery {
+		return nil
+	}
+	m.searchQuery = query
+	m.searchSeq++
+	seq := m.searchSeq
+	return tea.Tick(searchDebounce, func(time.Time) tea.Msg {
+		return branchSearchMsg{seq: seq, query: query}
+	})
+}
+
+// presetsForSelected は選択中のワークフローのプリセットを返します
+func (m model) presetsForSelected() []preset.Preset {
+	return preset.ForWorkflow(m.presets, repoFullName(m.host, m.owner, m.repo), m.selectedWorkflow.fileName)
+}
+
+// showWorkflows はワークフロー選択画面へ戻ります
+func (m *model) showWorkflows() tea.Cmd {
+	m.state = selectingWorkflow
+	m.list.AdditionalShortHelpKeys = nil
+	m.list.Title = "Select a Workflow"
+	m.list.ResetFilter()
+	cmd := m.list.SetItems(m.workflows)
+
+	// 直前に選んだワークフローを選択状態にする
+	for idx, it := range m.workflows {
+		if it.(item).fileName == m.selectedWorkflow.fileName {
+			m.list.Select(idx)
+			break
+		}
+	}
+	return cmd
+}
+
+// showRefPicker は選択済みの ref の種類に応じてブランチまたはタグの選択画面へ戻ります
+func (m *model) showRefPicker() tea.Cmd {
+	if m.selectedBranch.refKind == branch.KindTag {
+		return m.showTags()
+	}
+	return m.showBranches()
+}
+
+// stepBack は inputs 入力中に Esc が押されたときに 1 つ前へ戻ります
+// 入力途中の値は妥当であれば保持し、確認画面からの編集中は変更せずに確認画面へ戻ります
+func (m *model) stepBack() tea.Cmd {
+	if m.editingInput {
+		m.editingInput = false
+		m.state = confirming
+		return nil
+	}
+
+	if m.field.validate() {
+		m.userInputs[m.field.key] = m.field.value()
+	}
+
+	if m.currentInputIdx > 0 {
+		m.currentInputIdx--
+		m.resetInput()
+		return nil
+	}
+	return m.showRefPicker()
+}
+
+// showPresets はプリセット選択画面へ進みます
+func (m *model) showPresets(presets []preset.Preset) tea.Cmd {
+	m.state = selectingPreset
+	m.list.AdditionalShortHelpKeys = nil
+	m.list.Title = fmt.Sprintf("Select a Preset for %s", m.selectedWorkflow.title)
+	m.list.ResetSelected()
+	m.list.ResetFilter()
+
+	items := []list.Item{item{title: "Enter inputs manually", desc: "Do not use a preset"}}
+	for _, p := range presets {
+		scope := "repo"
+		if p.Repo != "" {
+			scope = "user"
+		}
+		items = append(items, item{
+			title:  p.Name,
+			desc:   fmt.Sprintf("[%s] %s", scope, formatInputs(p.Inputs, m.selectedWorkflow.inputNames)),
+			preset: &p,
+		})
+	}
+	return m.list.SetItems(items)
+}
+
+// errorMessageLifetime はリストのステータスに表示するエラーの表示時間です
+const errorMessageLifetime = 5 * time.Second
+
+// listError はリストのステータスにエラーを表示します
+// 見落とさないよう、通常のステータスメッセージより長く表示します
+func (m *model) listError(text string) tea.Cmd {
+	lifetime := m.list.StatusMessageLifetime
+	m.list.StatusMessageLifetime = errorMessageLifetime
+	cmd := m.list.NewStatusMessage(errorStyle.Render(text))
+	m.list.StatusMessageLifetime = lifetime
+	return cmd
+}
+
+// refHelpKeys は ref 選択画面で使えるキーの説明です
+func refHelpKeys() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "branches/tags")),
+		key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "type a ref")),
+		key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sort branches")),
+	}
+}
+
+// showTags はタグ選択画面へ切り替えます。未取得の場合は取得するコマンドを返します
+func (m *model) showTags() tea.Cmd {
+	m.state = selectingBranch
+	m.refKind = branch.KindTag
+	m.list.AdditionalShortHelpKeys = refHelpKeys
+	m.list.ResetSelected()
+	m.list.ResetFilter()
+
+	// 取得に失敗した場合は Enter で再試行されるまでエラーを表示する
+	if m.tagsErr != nil {
+		m.list.Title = "Select a Tag"
+		return m.list.SetItems([]list.Item{})
+	}
+
+	if !m.tagsLoaded {
+		m.list.Title = "Select a Tag (loading...)"
+		if m.tagsLoading {
+			return m.list.SetItems([]list.Item{})
+		}
+		m.tagsLoading = true
+		return tea.Batch(m.list.SetItems([]list.Item{}), fetchTags(m.client, m.owner, m.repo))
+	}
+
+	m.list.Title = "Select a Tag"
+	return m.list.SetItems(m.tags)
+}
+
+// showBranches はブランチ選択画面へ進みます
+func (m *model) showBranches() tea.Cmd {
+	m.state = selectingBranch
+	m.refKind = branch.KindBranch
+	m.list.AdditionalShortHelpKeys = refHelpKeys
+	m.list.Title = fmt.Sprintf("Select a Branch (Current: %s)", m.currentBranch)
+	if m.sortByRecent {
+		m.list.Title += " · recently updated first"
+	}
+	if m.searcher != nil {
+		m.list.Title += " · filter searches GitHub"
+	}
+	m.list.ResetSelected()
+	m.list.ResetFilter()
+
+	// カレントブランチをデフォルト選択にする
+	newItems := branchItems(m.branches, m.sortByRecent, time.Now())
+	cmd := m.list.SetItems(newItems)
+
+	// 検索モードでは直前の検索結果が残っているため、最近更新されたブランチに戻す
+	if m.searcher != nil {
+		m.searchQuery = ""
+		cmd = tea.Batch(cmd, searchBranches(m.searcher, ""))
+	}
+
+	for idx, it := range newItems {
+		if it.(item).title == m.currentBranch {
+			m.list.Select(idx)
+			break
+		}
+	}
+
+	return cmd
+}
+
+// branchItems はブランチ一覧をリストの項目に変換します
+// 説明にはデフォルトブランチ・保護状態・最新コミットの日時と作成者を表示します
+func branchItems(branches []branch.Branch, sortByRecent bool, now time.Time) []list.Item {
+	branches = slices.Clone(branches)
+	if sortByRecent {
+		branch.SortByRecent(branches)
+	}
+
+	items := []list.Item{}
+	for _, b := range branches {
+		var details []string
+		if b.Default {
+			details = append(details, "★ default")
+		}
+		if b.Protected {
+			details = append(details, "🔒 protected")
+		}
+		if !b.CommittedAt.IsZero() {
+			updated := "updated " + timeAgo(b.CommittedAt, now)
+			if b.Author != "" {
+				updated += " by " + b.Author
+			}
+			details = append(details, updated)
+		}
+
+		desc := "Branch"
+		if len(details) > 0 {
+			desc = strings.Join(details, " · ")
+		}
+		items = append(items, item{title: b.Name, desc: desc, refKind: branch.KindBranch})
+	}
+	return items
+}
+
+// timeAgo は t が now からどれくらい前かを人が読みやすい形で返します
+func timeAgo(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+	return "on " + t.Local().Format("2006-01-02")
+}
+
+// savePreset は入力済みの値をプリセットとして保存し、結果のメッセージを返します
+func (m *model) savePreset() string {
+	p := preset.Preset{
+		Name:     strings.TrimSpace(m.presetNameInput.Value()),
+		Workflow: m.selectedWorkflow.fileName,
+		Inputs:   m.userInputs,
+	}
+	path := preset.RepoPath(m.rootPath)
+	if m.presetUserScope {
+		p.Repo = repoFullName(m.host, m.owner, m.repo)
+		path = preset.UserPath()
+	}
+
+	if err := preset.Save(path, p); err != nil {
+		return "✗ " + err.Error()
+	}
+	if presets, err := loadPresets(m.rootPath); err == nil {
+		m.presets = presets
+	}
+	return fmt.Sprintf("✓ Saved preset %q to %s", p.Name, path)
+}
+
+// refLabel は ref の種類の表示名を返します
+func refLabel(kind string) string {
+	switch kind {
+	case branch.KindBranch:
+		return "Branch"
+	case branch.KindTag:
+		return "Tag"
+	}
+	return "Ref"
+}
+
+// formatInputs は inputs を key=value 形式で並べた文字列を返します
+func formatInputs(inputs map[string]string, order []string) string {
+	var pairs []string
+	for _, key := range order {
+		if value, ok := inputs[key]; ok {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// startInputs は選択中のワークフローの inputs 入力を開始します
+// inputs がない場合や、事前に与えられた値ですべての input が満たされる場合は確認画面へ進みます
+func (m *model) startInputs() {
+	m.checkSync()
+	m.workflowInputs = m.inputSchema()
+	m.inputKeys = m.selectedWorkflow.inputNames
+	entered := m.userInputs
+	m.userInputs = make(map[string]string)
+	m.ignoredInputs = nil
+	m.confirmIdx = 0
+	m.editingInput = false
+
+	// プリセットの値を --inputs-file の値で上書きしたものを初期値にする
+	prefilled := maps.Clone(m.presetInputs)
+	if prefilled == nil {
+		prefilled = map[string]string{}
+	}
+	maps.Copy(prefilled, m.prefilledInputs)
+	for key, value := range prefilled {
+		if _, ok := m.workflowInputs[key]; ok {
+			m.userInputs[key] = value
+		} else {
+			m.ignoredInputs = append(m.ignoredInputs, key)
+		}
+	}
+	sort.Strings(m.ignoredInputs)
+
+	// ref の選択へ戻ってきた場合は入力済みの値を引き継ぐ
+	for key, value := range entered {
+		if _, ok := m.workflowInputs[key]; ok {
+			m.userInputs[key] = value
+		}
+	}
+
+	if len(m.workflowInputs) == 0 {
+		m.state = confirming
+		return
+	}
+
+	if len(m.userInputs) > 0 {
+		values := workflow.ApplyDefaults(m.workflowInputs, m.userInputs)
+		if workflow.ValidateInputs(m.workflowInputs, values) == nil {
+			m.userInputs = values
+			m.state = confirming
+			return
+		}
+	}
+
+	if m.formMode() {
+		m.startForm(0)
+		return
+	}
+
+	m.state = enteringInputs
+	m.currentInputIdx = 0
+	m.resetInput()
+}
+
+// checkSync は選択したブランチのローカルとリモートの差分を確認します
+// タグや、ローカルに存在しないブランチの場合は何も表示しません
+func (m *model) checkSync() {
+	m.syncStatus = nil
+	if m.selectedBranch.refKind == branch.KindTag {
+		return
+	}
+	if status, err := gitsync.Compare(m.rootPath, m.selectedBranch.title, m.owner, m.repo); err == nil {
+		m.syncStatus = status
+	}
+}
+
+// resetInput は現在の input の入力欄を初期化します
+// 事前に与えられた値があればそれを初期値にします
+func (m *model) resetInput() {
+	key := m.inputKeys[m.currentInputIdx]
+	m.field = newFormField(key, m.workflowInputs[key], m.userInputs)
+	m.field.text.Focus()
+}
+
+// newTextInput は入力欄のスタイルを揃えた textinput を作成します
+func newTextInput(placeholder string) textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.Placeholder = placeholder
+	ti.TextStyle = inputStyle
+	ti.PlaceholderStyle = placeholderStyle
+	ti.Cursor.Style = inputStyle
+	// 点滅のためのメッセージを扱わずに済むよう、カーソルは点滅させない
+	ti.Cursor.SetMode(cursor.CursorStatic)
+	ti.Focus()
+	return ti
+}
+
+// textInputView は入力欄を描画します
+// textinput は Width を指定しないとプレースホルダーの先頭 1 文字しか表示しないため、未入力時は自前で描画します
+func textInputView(ti textinput.Model) string {
+	if ti.Value() != "" || ti.Placeholder == "" {
+		return ti.View()
+	}
+	if !ti.Focused() {
+		return placeholderStyle.Render(ti.Placeholder)
+	}
+	return inputStyle.Render("█") + placeholderStyle.Render(ti.Placeholder)
+}
+
+// isChoice は選択肢から選ぶ input かどうかを判定します
+// type: environment の input は環境一覧が取得できた場合に選択肢として扱う
+func isChoice(input workflow.Input) bool {
+	return (input.Type == workflow.InputTypeChoice || input.Type == workflow.InputTypeEnvironment) && len(input.Options) > 0
+}
+
+// numberPrefixPattern は入力途中の数値 ("-", "1." など) も許容するパターン
+var numberPrefixPattern = regexp.MustCompile(`^-?[0-9]*\.?[0-9]*$`)
+
+// isNumberPrefix は入力途中の文字列が数値になり得るか判定します
+func isNumberPrefix(s string) bool {
+	return numberPrefixPattern.MatchString(s)
+}
+
 func (m model) View() string {
+	if m.state == fillingForm {
+		return m.formView()
+	}
 	if m.state == enteringInputs {
 		key := m.inputKeys[m.currentInputIdx]
 		input := m.workflowInputs[key]
@@ -218,6 +1054,13 @@ func (m model) View() string {
 			output.WriteString("\n")
 		}
 
+		// Type
+		if input.Type != "" {
+			output.WriteString(labelStyle.Render("Type: "))
+			output.WriteString(input.Type)
+			output.WriteString("\n")
+		}
+
 		// Required
 		if input.Required {
 			output.WriteString(requiredStyle.Render("Required: yes"))
@@ -232,12 +1075,131 @@ func (m model) View() string {
 		}
 
 		output.WriteString("\n")
-		output.WriteString(labelStyle.Render("Value: "))
-		output.WriteString(inputStyle.Render(m.inputBuffer))
-		output.WriteString(inputStyle.Render("█")) // カーソル
+		if m.field.err != "" {
+			output.WriteString(errorStyle.Render("✗ " + m.field.err))
+			output.WriteString("\n")
+		}
+		if input.Type == workflow.InputTypeEnvironment && m.environmentsErr != nil {
+			output.WriteString(labelStyle.Render("Could not load environments, enter a name: " + m.environmentsErr.Error()))
+			output.WriteString("\n")
+		}
+		switch {
+		case isChoice(input):
+			for idx, opt := range m.field.choices() {
+				label := m.field.choiceLabel(opt)
+				if idx == m.field.choiceIdx {
+					output.WriteString(inputStyle.Render("> " + label))
+				} else {
+					output.WriteString(labelStyle.Render("  " + label))
+				}
+				if summary := m.environmentSummary(input, opt); summary != "" {
+					output.WriteString(labelStyle.Render("  (" + summary + ")"))
+				}
+				output.WriteString("\n")
+			}
+
+			output.WriteString(hintStyle.Render("Use ↑/↓ to choose, Enter to continue, Esc to go back, Ctrl+C to cancel"))
+		case input.Type == workflow.InputTypeBoolean:
+			output.WriteString(labelStyle.Render("Value: "))
+			output.WriteString(m.field.valueView(true))
+			output.WriteString("\n")
+			output.WriteString(hintStyle.Render("Use ←/→ or Space to toggle, Enter to continue, Esc to go back, Ctrl+C to cancel"))
+		default:
+			output.WriteString(labelStyle.Render("Value: "))
+			output.WriteString(m.field.valueView(true))
+
+			output.WriteString("\n")
+			if input.Type == workflow.InputTypeNumber {
+				output.WriteString(hintStyle.Render("Numbers only. Press Enter to continue (or use default), Esc to go back, Ctrl+C to cancel"))
+			} else {
+				output.WriteString(hintStyle.Render("Press Enter to continue (or use default), Esc to go back, Ctrl+C to cancel"))
+			}
+		}
+
+		return docStyle.Render(output.String())
+	}
+	if m.state == selectingBranch && m.refKind == branch.KindBranch && (m.branchesLoading || m.branchesErr != nil) {
+		var output strings.Builder
+
+		output.WriteString(titleStyle.Render(m.list.Title))
+		output.WriteString("\n\n")
 
+		if m.branchesLoading {
+			output.WriteString(m.spinner.View())
+			output.WriteString(" Loading branches...")
+			output.WriteString("\n")
+			output.WriteString(hintStyle.Render("Tab: select a tag · r: type a ref · Ctrl+C: cancel"))
+		} else {
+			output.WriteString(errorStyle.Render("✗ Failed to load branches: " + m.branchesErr.Error()))
+			output.WriteString("\n")
+			output.WriteString(hintStyle.Render("Enter: retry · Tab: select a tag · r: type a ref · Ctrl+C: cancel"))
+		}
+
+		return docStyle.Render(output.String())
+	}
+	if m.state == selectingBranch && m.refKind == branch.KindTag && m.tagsErr != nil {
+		var output strings.Builder
+
+		output.WriteString(titleStyle.Render(m.list.Title))
+		output.WriteString("\n\n")
+		output.WriteString(errorStyle.Render("✗ Failed to load tags: " + m.tagsErr.Error()))
 		output.WriteString("\n")
-		output.WriteString(hintStyle.Render("Press Enter to continue (or use default), Ctrl+C to cancel"))
+		output.WriteString(hintStyle.Render("Enter: retry · Tab: select a branch · r: type a ref · Ctrl+C: cancel"))
+
+		return docStyle.Render(output.String())
+	}
+	if m.state == enteringRef {
+		var output strings.Builder
+
+		output.WriteString(titleStyle.Render("Type a Ref"))
+		output.WriteString("\n\n")
+
+		output.WriteString(labelStyle.Render("Branch or tag: "))
+		output.WriteString(textInputView(m.refInput))
+		output.WriteString("\n")
+
+		if m.resolvingRef {
+			output.WriteString(labelStyle.Render("Checking..."))
+			output.WriteString("\n")
+		} else if m.refErr != "" {
+			output.WriteString(errorStyle.Render("✗ " + m.refErr))
+			output.WriteString("\n")
+		}
+
+		output.WriteString(hintStyle.Render("Press Enter to check and use the ref, Esc to go back"))
+
+		return docStyle.Render(output.String())
+	}
+	if m.state == savingPreset {
+		var output strings.Builder
+
+		output.WriteString(titleStyle.Render("Save Inputs as Preset"))
+		output.WriteString("\n\n")
+
+		output.WriteString(labelStyle.Render("Workflow: "))
+		output.WriteString(valueStyle.Render(m.selectedWorkflow.title))
+		output.WriteString("\n")
+		output.WriteString(labelStyle.Render("Inputs: "))
+		output.WriteString(formatInputs(m.userInputs, m.inputKeys))
+		output.WriteString("\n\n")
+
+		output.WriteString(labelStyle.Render("Name: "))
+		output.WriteString(textInputView(m.presetNameInput))
+		output.WriteString("\n\n")
+
+		output.WriteString(labelStyle.Render("Save to: "))
+		repoLabel := "repository (" + filepath.Join(".github", "dispatch-presets.yml") + ")"
+		userLabel := "user (" + preset.UserPath() + ")"
+		if m.presetUserScope {
+			output.WriteString(labelStyle.Render("( ) " + repoLabel + "  "))
+			output.WriteString(inputStyle.Render("(•) " + userLabel))
+		} else {
+			output.WriteString(inputStyle.Render("(•) " + repoLabel))
+			output.WriteString(labelStyle.Render("  ( ) " + userLabel))
+		}
+
+		output.WriteString("\n")
+		output.WriteString(hintStyle.Render("Press Tab to switch location, Enter to save, Esc to go back"))
 
 		return docStyle.Render(output.String())
 	}
@@ -245,7 +1207,11 @@ func (m model) View() string {
 		var output strings.Builder
 
 		// タイトル
-		output.WriteString(titleStyle.Render("Confirm Dispatch"))
+		title := "Confirm Dispatch"
+		if m.dryRun {
+			title += " (dry run)"
+		}
+		output.WriteString(titleStyle.Render(title))
 		output.WriteString("\n\n")
 
 		// Workflow
@@ -253,32 +1219,96 @@ func (m model) View() string {
 		output.WriteString(valueStyle.Render(m.selectedWorkflow.title))
 		output.WriteString("\n\n")
 
-		// Branch
-		output.WriteString(labelStyle.Render("Branch: "))
+		// Ref
+		output.WriteString(labelStyle.Render(refLabel(m.selectedBranch.refKind) + ": "))
 		output.WriteString(valueStyle.Render(m.selectedBranch.title))
 		output.WriteString("\n")
+		if m.syncStatus != nil && !m.syncStatus.InSync() {
+			output.WriteString(errorStyle.Render("⚠ " + m.syncStatus.Warning()))
+			output.WriteString("\n")
+		}
 
 		// Inputs
 		if len(m.userInputs) > 0 {
 			output.WriteString("\n")
 			output.WriteString(labelStyle.Render("Inputs:"))
 			output.WriteString("\n")
-			for key, value := range m.userInputs {
-				output.WriteString(labelStyle.Render("  • "))
+			for idx, key := range m.inputKeys {
+				if idx == m.confirmIdx {
+					output.WriteString(inputStyle.Render("  > "))
+				} else {
+					output.WriteString(labelStyle.Render("  • "))
+				}
 				output.WriteString(labelStyle.Render(key + ": "))
-				output.WriteString(valueStyle.Render(value))
+				output.WriteString(valueStyle.Render(m.userInputs[key]))
+				if summary := m.environmentSummary(m.workflowInputs[key], m.userInputs[key]); summary != "" {
+					output.WriteString(labelStyle.Render(" (" + summary + ")"))
+				}
 				output.WriteString("\n")
 			}
 		}
 
+		if len(m.ignoredInputs) > 0 {
+			output.WriteString("\n")
+			output.WriteString(errorStyle.Render("Ignored inputs not defined in this workflow: " + strings.Join(m.ignoredInputs, ", ")))
+			output.WriteString("\n")
+		}
+
+		if m.showRequest {
+			output.WriteString("\n")
+			if preview, err := dispatchPreview(m.host, m.dispatchParams()); err != nil {
+				output.WriteString(errorStyle.Render(err.Error()))
+			} else {
+				output.WriteString(valueStyle.Render(preview))
+			}
+			output.WriteString("\n")
+		}
+
+		if m.showCommands {
+			dispatchCmd, workflowCmd := m.reproduceCommands()
+			output.WriteString("\n")
+			output.WriteString(labelStyle.Render("Reproduce with:"))
+			output.WriteString("\n")
+			output.WriteString(valueStyle.Render("  " + dispatchCmd))
+			output.WriteString("\n")
+			output.WriteString(valueStyle.Render("  " + workflowCmd))
+			output.WriteString("\n")
+		}
+
+		if m.statusMsg != "" {
+			output.WriteString("\n")
+			output.WriteString(labelStyle.Render(m.statusMsg))
+			output.WriteString("\n")
+		}
+
 		output.WriteString("\n")
-		output.WriteString(hintStyle.Render("Are you sure? (y/N)"))
+		hints := []string{"Are you sure? (y/N)"}
+		if m.dryRun {
+			hints[0] = "Print the request without dispatching? (y/N)"
+		}
+		if m.showRequest {
+			hints = append(hints, "d: hide request")
+		} else {
+			hints = append(hints, "d: show request")
+		}
+		hints = append(hints, "c: copy as command")
+		if len(m.userInputs) > 0 {
+			hints = append(hints, "↑/↓ e: edit input", "s: save inputs as preset")
+		}
+		hints = append(hints, "esc: back")
+		if m.syncStatus != nil && m.syncStatus.CanPush() {
+			hints = append(hints, "p: push to "+m.syncStatus.Remote)
+		}
+		output.WriteString(hintStyle.Render(strings.Join(hints, " · ")))
 
 		return docStyle.Render(output.String())
 	}
 	if m.state == executing {
 		return "" // 実行ログはmain関数側で出力するため何も表示しない
 	}
+	if m.state == watching {
+		return m.watchView()
+	}
 	if m.quitting {
 		return "\nQuit.\n"
 	}
@@ -286,109 +1316,398 @@ func (m model) View() string {
 }
 
 // --- Main ---
-func main() {
-	// 1. 実行ディレクトリのリポジトリ情報を取得
-	repoInfo, err := repository.Current()
+
+// repoContext はコマンド実行に必要なリポジトリ情報をまとめたものです
+type repoContext struct {
+	host          string
+	owner         string
+	repo          string
+	rootPath      string
+	client        *retry.Client
+	gqlClient     *retry.GraphQL
+	workflows     []workflow.Workflow
+	currentBranch string
+}
+
+// loadRepoContext は対象のリポジトリ情報と、実行ディレクトリのワークフロー一覧を取得します
+func loadRepoContext(flags repoFlags) (*repoContext, error) {
+	// 1. 対象のリポジトリ情報を取得 (--repo / --hostname がなければ実行ディレクトリから)
+	repoInfo, err := flags.resolve()
 	if err != nil {
-		log.Fatal("Could not determine current repository. Are you in a git-managed directory with a remote?")
+		return nil, err
 	}
 
-	owner, repo := repoInfo.Owner, repoInfo.Name
-
 	// リポジトリのルートパスを取得
 	rootPath := ""
 	if out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output(); err == nil {
 		rootPath = strings.TrimSpace(string(out))
 	} else {
-		log.Fatal("Could not determine repository root. Are you in a git-managed directory?")
+		return nil, fmt.Errorf("could not determine repository root. Are you in a git-managed directory?")
 	}
 
-	client, err := api.DefaultRESTClient()
+	// GitHub Enterprise Server でも動くよう、リポジトリのホストに向けたクライアントを作る
+	opts := api.ClientOptions{Host: repoInfo.Host}
+	restClient, err := rest.New(opts)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	// 一時的なエラーやレートリミットで失敗したリクエストを再試行する
+	client := retry.New(restClient)
+
+	gqlClient, err := api.NewGraphQLClient(opts)
+	if err != nil {
+		return nil, err
 	}
+	// ブランチ一覧の GraphQL クエリも同じ規則で再試行する
+	// (失敗すると REST に切り替わり、ブランチのメタデータが表示されなくなるため)
+	gqlRetryClient := retry.NewGraphQL(gqlClient)
 
 	// 2. Workflow 一覧取得 (internalパッケージを使用)
 	workflowsDir := filepath.Join(rootPath, ".github", "workflows")
 	wfs, err := workflow.LoadDispatchableWorkflows(workflowsDir)
 	if err != nil {
-		log.Fatalf("Failed to scan workflows: %v", err)
+		return nil, fmt.Errorf("failed to scan workflows: %w", err)
 	}
 
-	if len(wfs) == 0 {
-		fmt.Println("No workflows with 'workflow_dispatch' trigger found in .github/workflows.")
-		return
+	// 3. カレントブランチ取得
+	currentBranch := ""
+	if out, err := exec.Command("git", "branch", "--show-current").Output(); err == nil {
+		currentBranch = strings.TrimSpace(string(out))
 	}
 
-	wfItems := []list.Item{}
-	for _, wf := range wfs {
-		wfItems = append(wfItems, item{
-			title:    wf.Name,
-			desc:     wf.Path,
-			fileName: wf.FileName,
-			inputs:   wf.Inputs,
+	return &repoContext{
+		host:          repoInfo.Host,
+		owner:         repoInfo.Owner,
+		repo:          repoInfo.Name,
+		rootPath:      rootPath,
+		client:        client,
+		gqlClient:     gqlRetryClient,
+		workflows:     wfs,
+		currentBranch: currentBranch,
+	}, nil
+}
+
+// fullName は履歴に記録するリポジトリ名を返します
+func (c *repoContext) fullName() string {
+	return repoFullName(c.host, c.owner, c.repo)
+}
+
+// dispatch はワークフローを実行し、作成された実行を出力して履歴に記録します
+func dispatch(ctx *repoContext, params workflow.DispatchParams, title string) (*workflow.Run, error) {
+	fmt.Printf("🚀 Dispatching %s on %s...\n", title, params.Ref)
+
+	client := ctx.client
+	since := time.Now().Add(-clockSkew)
+	params.ReturnRunDetails = supportsRunDetails(ctx.host)
+	run, err := workflow.RunDispatch(client, params)
+	if err != nil {
+		return nil, dispatchError(err)
+	}
+
+	fmt.Println("✅ Successfully dispatched!")
+
+	// API が実行の情報を返さなかった場合は一覧から探す
+	if run == nil {
+		fmt.Println("🔎 Looking for the created run...")
+		run, err = workflow.FindDispatchedRun(client, workflow.RunQuery{
+			Owner:        params.Owner,
+			Repo:         params.Repo,
+			WorkflowFile: params.WorkflowFile,
+			Ref:          params.Ref,
+			Actor:        currentUser(client),
+			Since:        since,
 		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
 	}
 
-	// 3. Branch 一覧取得
-	brRes, err := branch.FetchBranches(client, owner, repo)
+	entry := history.Entry{
+		Repo:         ctx.fullName(),
+		Workflow:     params.WorkflowFile,
+		WorkflowName: title,
+		Ref:          params.Ref,
+		Inputs:       params.Inputs,
+		Time:         time.Now(),
+	}
+	if run != nil {
+		entry.RunURL = run.HTMLURL
+	}
+	if err := history.Append(history.DefaultPath(), entry); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not record dispatch history: %v\n", err)
+	}
+
+	if run != nil {
+		fmt.Printf("\nRun ID: %d\nURL: %s\n", run.ID, run.HTMLURL)
+		return run, nil
+	}
+
+	fmt.Printf("\nFor more information about the run, try:\n  gh run list --workflow=%s\n", params.WorkflowFile)
+	return nil, nil
+}
+
+// dispatchError は dispatch の失敗を、API エラーであれば解決のヒントを添えて返します
+func dispatchError(err error) error {
+	var apiErr *workflow.APIError
+	if !errors.As(err, &apiErr) {
+		return fmt.Errorf("❌ Failed to dispatch: %w", err)
+	}
+
+	details := ""
+	if hint := apiErr.Hint(); hint != "" {
+		details += "\n💡 " + hint
+	}
+	if apiErr.DocumentationURL != "" {
+		details += "\n📖 " + apiErr.DocumentationURL
+	}
+	return fmt.Errorf("❌ Failed to dispatch: %w%s", err, details)
+}
+
+// exitError は実行の結果に応じた終了コードでプログラムを終了させるためのエラーです
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// exitCodeStopped は実行の完了前に監視をやめた場合の終了コードです
+// 実行の結果 (0 または 1) やフラグのエラー (2) と区別できるようにします
+const exitCodeStopped = 3
+
+// finishRun は完了した実行の結果を出力し、conclusion に応じた終了コードのエラーを返します
+// 完了前に監視をやめた場合は exitCodeStopped を返します
+func finishRun(run *workflow.Run) error {
+	if !run.Completed() {
+		fmt.Printf("\nStopped watching run #%d before it completed; the run keeps going\n", run.ID)
+		return &exitError{code: exitCodeStopped}
+	}
+
+	fmt.Printf("\n%s Run #%d completed: %s\n", statusIcon(run.Status, run.Conclusion), run.ID, run.Conclusion)
+	if code := run.ExitCode(); code != 0 {
+		return &exitError{code: code}
+	}
+	return nil
+}
+
+// clockSkew はローカルと GitHub の時刻のずれを考慮して、実行を探す開始時刻をさかのぼらせる幅です
+const clockSkew = 5 * time.Second
+
+// currentUser は認証中のユーザー名を返します。取得できない場合 (GitHub App のトークンなど) は空文字を返します
+func currentUser(client *retry.Client) string {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := client.Get("user", &user); err != nil {
+		return ""
+	}
+	return user.Login
+}
+
+// loadPresets はリポジトリ単位とユーザー単位のプリセットを読み込みます
+func loadPresets(rootPath string) ([]preset.Preset, error) {
+	repoPresets, err := preset.Load(preset.RepoPath(rootPath))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	userPresets, err := preset.Load(preset.UserPath())
+	if err != nil {
+		return nil, err
 	}
+	return append(repoPresets, userPresets...), nil
+}
 
-	brItems := []list.Item{}
-	for _, b := range brRes {
-		brItems = append(brItems, item{title: b.Name, desc: "Branch"})
+// loadInputsFile は --inputs-file で指定されたファイル ("-" の場合は標準入力) から input の値を読み込みます
+func loadInputsFile(path string) (map[string]string, error) {
+	if path == "-" {
+		return workflow.ParseInputValues(os.Stdin)
 	}
 
-	// 4. カレントブランチ取得
-	currentBranch := ""
-	if out, err := exec.Command("git", "branch", "--show-current").Output(); err == nil {
-		currentBranch = strings.TrimSpace(string(out))
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open inputs file: %w", err)
 	}
+	defer f.Close()
 
-	// 5. Bubble Tea 実行
-	initialModel := model{
-		state:         selectingWorkflow,
-		workflows:     wfItems,
-		branches:      brItems,
-		list:          list.New(wfItems, list.NewDefaultDelegate(), 0, 0),
-		owner:         owner,
-		repo:          repo,
-		currentBranch: currentBranch,
+	return workflow.ParseInputValues(f)
+}
+
+// workflowItems はワークフロー一覧をリストの項目に変換します
+func workflowItems(wfs []workflow.Workflow) []list.Item {
+	items := []list.Item{}
+	for _, wf := range wfs {
+		items = append(items, item{
+			title:      wf.Name,
+			desc:       wf.Path,
+			fileName:   wf.FileName,
+			inputs:     wf.Inputs,
+			inputNames: wf.InputNames,
+		})
+	}
+	return items
+}
+
+// newModel はワークフロー選択画面から始まる Bubble Tea のモデルを作成します
+func newModel(ctx *repoContext) (model, error) {
+	presets, err := loadPresets(ctx.rootPath)
+	if err != nil {
+		return model{}, err
 	}
-	initialModel.list.Title = "Select a Workflow"
 
-	p := tea.NewProgram(initialModel, tea.WithAltScreen())
+	wfItems := workflowItems(ctx.workflows)
+
+	// Branch 一覧はワークフロー選択中にバックグラウンドで取得する (Init を参照)
+	m := model{
+		state:           selectingWorkflow,
+		workflows:       wfItems,
+		branchesLoading: true,
+		gqlClient:       ctx.gqlClient,
+		spinner:         spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(inputStyle)),
+		list:            list.New(wfItems, list.NewDefaultDelegate(), 0, 0),
+		host:            ctx.host,
+		owner:           ctx.owner,
+		repo:            ctx.repo,
+		currentBranch:   ctx.currentBranch,
+		rootPath:        ctx.rootPath,
+		presets:         presets,
+		client:          ctx.client,
+		formThreshold:   defaultFormThreshold,
+	}
+	m.list.Title = "Select a Workflow"
+	return m, nil
+}
+
+// dispatchParams は確認画面の内容から dispatch のパラメータを組み立てます
+func (m model) dispatchParams() workflow.DispatchParams {
+	return workflow.DispatchParams{
+		Owner:        m.owner,
+		Repo:         m.repo,
+		WorkflowFile: m.selectedWorkflow.fileName,
+		Ref:          m.selectedBranch.title,
+		Inputs:       m.userInputs,
+		Schema:       m.workflowInputs,
+	}
+}
+
+// runProgram は TUI を実行し、確認画面で承認された場合にワークフローを実行します
+func runProgram(ctx *repoContext, m model, opts ...tea.ProgramOption) error {
+	p := tea.NewProgram(m, append([]tea.ProgramOption{tea.WithAltScreen()}, opts...)...)
 	finalModelMsg, err := p.Run()
 	if err != nil {
-		fmt.Printf("Error running program: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("error running program: %w", err)
 	}
 
 	finalModel := finalModelMsg.(model)
+	if finalModel.state != executing {
+		return nil
+	}
 
-	// 5. 最終実行 (Dispatch)
-	if finalModel.state == executing {
-		fmt.Printf("🚀 Dispatching %s on branch %s...\n", finalModel.selectedWorkflow.title, finalModel.selectedBranch.title)
+	params := finalModel.dispatchParams()
+	if finalModel.dryRun {
+		preview, err := dispatchPreview(ctx.host, params)
+		if err != nil {
+			return err
+		}
+		fmt.Println(preview)
+		return nil
+	}
 
-		// ファイル名を使用
-		workflowFile := finalModel.selectedWorkflow.fileName
+	run, err := dispatch(ctx, params, finalModel.selectedWorkflow.title)
+	if err != nil || !finalModel.watch {
+		return err
+	}
+	if run == nil {
+		return fmt.Errorf("could not find the created run to watch")
+	}
 
-		params := workflow.DispatchParams{
-			Owner:        finalModel.owner,
-			Repo:         finalModel.repo,
-			WorkflowFile: workflowFile,
-			Ref:          finalModel.selectedBranch.title,
-			Inputs:       finalModel.userInputs,
-		}
+	// 同じモデルで実行の監視画面を表示する (終了後も結果が残るよう AltScreen は使わない)
+	finalModel.state = watching
+	finalModel.run = run
+	watchModelMsg, err := tea.NewProgram(finalModel, opts...).Run()
+	if err != nil {
+		return fmt.Errorf("error running program: %w", err)
+	}
+
+	return finishRun(watchModelMsg.(model).run)
+}
 
-		err := workflow.RunDispatch(client, params)
+// interactiveCommand はワークフローを対話的に選択して実行する `gh dispatch` を処理します
+func interactiveCommand(args []string) error {
+	fs := flag.NewFlagSet("gh dispatch", flag.ExitOnError)
+	inputsFile := fs.String("inputs-file", "", "read input values from a JSON or YAML `file` (\"-\" for stdin)")
+	presetName := fs.String("preset", "", "use the saved preset `name` for the selected workflow")
+	watch := fs.Bool("watch", false, "watch the jobs of the created run and exit with a status matching its conclusion")
+	dryRun := fs.Bool("dry-run", false, "print the request and an equivalent gh api command instead of dispatching")
+	formThreshold := fs.Int("form-threshold", defaultFormThreshold, "enter inputs in a single-screen form when a workflow has more than `N` inputs")
+	var target repoFlags
+	target.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gh dispatch [--inputs-file FILE] [--preset NAME] [--watch] [--dry-run] [--form-threshold N] [--repo [HOST/]OWNER/REPO] [--hostname HOST]")
+		fmt.Fprintln(fs.Output(), "       gh dispatch run <workflow> [flags]")
+		fmt.Fprintln(fs.Output(), "       gh dispatch history [flags]")
+		fmt.Fprintln(fs.Output(), "       gh dispatch again [<number>]\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var prefilled map[string]string
+	if *inputsFile != "" {
+		values, err := loadInputsFile(*inputsFile)
 		if err != nil {
-			log.Fatalf("❌ Failed to dispatch: %v", err)
+			return err
+		}
+		prefilled = values
+	}
+
+	ctx, err := loadRepoContext(target)
+	if err != nil {
+		return err
+	}
+
+	if len(ctx.workflows) == 0 {
+		fmt.Println("No workflows with 'workflow_dispatch' trigger found in .github/workflows.")
+		return nil
+	}
+
+	m, err := newModel(ctx)
+	if err != nil {
+		return err
+	}
+	m.prefilledInputs = prefilled
+	m.presetName = *presetName
+	m.watch = *watch
+	m.dryRun = *dryRun
+	m.formThreshold = *formThreshold
+
+	var opts []tea.ProgramOption
+	if *inputsFile == "-" {
+		// 標準入力は inputs の読み込みに使ったため、キー入力は TTY から受け取る
+		opts = append(opts, tea.WithInputTTY())
+	}
+
+	return runProgram(ctx, m, opts...)
+}
+
+func main() {
+	commands := map[string]func([]string) error{
+		"run":     runCommand,
+		"history": historyCommand,
+		"again":   againCommand,
+	}
+
+	command, args := interactiveCommand, os.Args[1:]
+	if len(os.Args) > 1 {
+		if c, ok := commands[os.Args[1]]; ok {
+			command, args = c, os.Args[2:]
 		}
+	}
 
-		fmt.Println("✅ Successfully dispatched!")
-		fmt.Printf("\nFor more information about the run, try:\n  gh run list --workflow=%s\n", workflowFile)
+	if err := command(args); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+		log.Fatal(err)
 	}
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+)
+
+// repoFlags は対象のホストとリポジトリを指定するフラグです
+type repoFlags struct {
+	hostname string
+	repo     string
+}
+
+// register は --hostname と --repo を fs に登録します
+func (f *repoFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.hostname, "hostname", "", "GitHub `host` to use, e.g. a GitHub Enterprise Server host (default: the host of the current repository)")
+	fs.StringVar(&f.repo, "repo", "", "target repository as `[HOST/]OWNER/REPO` (default: the repository of the current directory)")
+}
+
+// resolve はフラグから対象のリポジトリを決定します
+// --repo も --hostname も指定されていない場合は実行ディレクトリのリポジトリを使います
+func (f repoFlags) resolve() (repository.Repository, error) {
+	switch {
+	case f.repo != "" && f.hostname != "":
+		r, err := repository.ParseWithHost(f.repo, f.hostname)
+		if err != nil {
+			return r, err
+		}
+		if !strings.EqualFold(r.Host, f.hostname) {
+			return r, fmt.Errorf("--repo %s does not belong to --hostname %s", f.repo, f.hostname)
+		}
+		return r, nil
+	case f.repo != "":
+		return repository.Parse(f.repo)
+	case f.hostname != "":
+		return currentRepoOnHost(f.hostname)
+	}
+
+	r, err := repository.Current()
+	if err != nil {
+		return r, fmt.Errorf("could not determine current repository. Are you in a git-managed directory with a remote?")
+	}
+	return r, nil
+}
+
+// currentRepoOnHost は実行ディレクトリの git リモートのうち、host を指すリポジトリを返します
+func currentRepoOnHost(host string) (repository.Repository, error) {
+	out, err := exec.Command("git", "remote", "-v").Output()
+	if err != nil {
+		return repository.Repository{}, fmt.Errorf("could not list git remotes. Are you in a git-managed directory?")
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		r, err := repository.Parse(fields[1])
+		if err == nil && strings.EqualFold(r.Host, host) {
+			return r, nil
+		}
+	}
+	return repository.Repository{}, fmt.Errorf("none of the git remotes point to %s, specify the repository with --repo", host)
+}
+
+// repoFullName は [HOST/]OWNER/REPO 形式のリポジトリ名を返します
+// --repo にそのまま渡せ、github.com 以外のホストでは同名のリポジトリと区別できるようホスト名を含めます
+func repoFullName(host, owner, repo string) string {
+	if host == "" || strings.EqualFold(host, "github.com") {
+		return owner + "/" + repo
+	}
+	return host + "/" + owner + "/" + repo
+}
+
+// supportsRunDetails は dispatch API の return_run_details を使えるホストかを返します
+// GitHub Enterprise Server はバージョンによって未対応のため、github.com と GHE.com のみで使います
+func supportsRunDetails(host string) bool {
+	host = strings.ToLower(host)
+	return host == "github.com" || strings.HasSuffix(host, ".ghe.com")
+}
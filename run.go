@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/yanskun/gh-dispatch/internal/environment"
+	"github.com/yanskun/gh-dispatch/internal/gitsync"
+	"github.com/yanskun/gh-dispatch/internal/preset"
+	"github.com/yanskun/gh-dispatch/internal/workflow"
+)
+
+// inputFlag は --input key=value を複数回指定できるようにする flag.Value です
+type inputFlag map[string]string
+
+func (f inputFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for k, v := range f {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f inputFlag) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected KEY=VALUE, got %q", s)
+	}
+	f[key] = value
+	return nil
+}
+
+// parseFlags はフラグと位置引数が混在していても解析し、位置引数を返します
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// runCommand は TUI を使わずにワークフローを実行する `gh dispatch run` を処理します
+func runCommand(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	ref := fs.String("ref", "", "branch or tag to run the workflow on (default: current branch)")
+	inputs := inputFlag{}
+	fs.Var(inputs, "input", "workflow input as `KEY=VALUE` (can be repeated, overrides --inputs-file)")
+	inputsFile := fs.String("inputs-file", "", "read input values from a JSON or YAML `file` (\"-\" for stdin, overrides --preset)")
+	presetName := fs.String("preset", "", "start from the input values of the saved preset `name`")
+	wait := fs.Bool("wait", false, "wait for the created run to complete and exit with a status matching its conclusion")
+	dryRun := fs.Bool("dry-run", false, "print the request and an equivalent gh api command instead of dispatching")
+	var target repoFlags
+	target.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gh dispatch run <workflow> [--ref REF] [--preset NAME] [--inputs-file FILE] [--input KEY=VALUE]... [--wait] [--dry-run] [--repo [HOST/]OWNER/REPO] [--hostname HOST]")
+		fmt.Fprintln(fs.Output(), "\n<workflow> is a workflow file name (e.g. deploy.yml) or its display name.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, err := loadRepoContext(target)
+	if err != nil {
+		return err
+	}
+
+	wf, err := workflow.FindWorkflow(ctx.workflows, positional[0])
+	if err != nil {
+		return err
+	}
+
+	// プリセット < --inputs-file < --input の順に値を上書きする
+	values := map[string]string{}
+	if *presetName != "" {
+		presets, err := loadPresets(ctx.rootPath)
+		if err != nil {
+			return err
+		}
+		p, err := preset.Find(preset.ForWorkflow(presets, ctx.fullName(), wf.FileName), *presetName)
+		if err != nil {
+			return err
+		}
+		maps.Copy(values, p.Inputs)
+	}
+	if *inputsFile != "" {
+		fileValues, err := loadInputsFile(*inputsFile)
+		if err != nil {
+			return err
+		}
+		maps.Copy(values, fileValues)
+	}
+	maps.Copy(values, inputs)
+
+	if *ref == "" {
+		*ref = ctx.currentBranch
+	}
+	if *ref == "" {
+		return fmt.Errorf("could not determine the current branch, specify --ref")
+	}
+	if status, _ := gitsync.Compare(ctx.rootPath, *ref, ctx.owner, ctx.repo); status != nil && !status.InSync() {
+		fmt.Fprintln(os.Stderr, "⚠️  "+status.Warning())
+	}
+
+	// inputs を持たないワークフローでも未定義の input を検出できるよう、空の定義で検証する
+	schema := wf.Inputs
+	if schema == nil {
+		schema = map[string]workflow.Input{}
+	}
+	// type: environment の input はリポジトリの環境一覧に含まれるかも検証する
+	if workflow.HasEnvironmentInput(schema) {
+		envs, err := environment.Fetch(ctx.client, ctx.owner, ctx.repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "⚠️  Could not check environment inputs: "+err.Error())
+		}
+		schema = workflow.WithEnvironments(schema, environment.Names(envs))
+	}
+
+	params := workflow.DispatchParams{
+		Owner:        ctx.owner,
+		Repo:         ctx.repo,
+		WorkflowFile: wf.FileName,
+		Ref:          *ref,
+		Inputs:       workflow.ApplyDefaults(schema, values),
+		Schema:       schema,
+	}
+
+	if *dryRun {
+		preview, err := dispatchPreview(ctx.host, params)
+		if err != nil {
+			return err
+		}
+		fmt.Println(preview)
+		return nil
+	}
+
+	run, err := dispatch(ctx, params, wf.Name)
+	if err != nil || !*wait {
+		return err
+	}
+	if run == nil {
+		return fmt.Errorf("could not find the created run to wait for")
+	}
+
+	fmt.Println("\n⏳ Waiting for the run to complete...")
+	run, err = waitForRun(ctx.client, ctx.owner, ctx.repo, run.ID)
+	if err != nil {
+		return err
+	}
+	return finishRun(run)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/yanskun/gh-dispatch/internal/shellquote"
+)
+
+// reproduceCommands は確認画面の内容を再現する `gh dispatch run` と `gh workflow run` のコマンドを返します
+func (m model) reproduceCommands() (dispatchCmd, workflowCmd string) {
+	repo := repoFullName(m.host, m.owner, m.repo)
+	file := m.selectedWorkflow.fileName
+	ref := m.selectedBranch.title
+
+	dispatchArgs := []string{"gh", "dispatch", "run", file, "--ref", ref}
+	workflowArgs := []string{"gh", "workflow", "run", file, "--ref", ref}
+	for _, key := range m.inputKeys {
+		value, ok := m.userInputs[key]
+		if !ok {
+			continue
+		}
+		dispatchArgs = append(dispatchArgs, "--input", key+"="+value)
+		workflowArgs = append(workflowArgs, "-f", key+"="+value)
+	}
+	dispatchArgs = append(dispatchArgs, "--repo", repo)
+	workflowArgs = append(workflowArgs, "--repo", repo)
+
+	return shellquote.Join(dispatchArgs...), shellquote.Join(workflowArgs...)
+}
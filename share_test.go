@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestReproduceCommands(t *testing.T) {
+	tests := []struct {
+		name         string
+		host         string
+		ref          string
+		inputKeys    []string
+		userInputs   map[string]string
+		wantDispatch string
+		wantWorkflow string
+	}{
+		{
+			name:         "inputs keep the workflow order",
+			host:         "github.com",
+			ref:          "main",
+			inputKeys:    []string{"environment", "version", "dry_run"},
+			userInputs:   map[string]string{"dry_run": "false", "environment": "staging", "version": "v1.2.3"},
+			wantDispatch: "gh dispatch run deploy.yml --ref main --input environment=staging --input version=v1.2.3 --input dry_run=false --repo user/repo",
+			wantWorkflow: "gh workflow run deploy.yml --ref main -f environment=staging -f version=v1.2.3 -f dry_run=false --repo user/repo",
+		},
+		{
+			name:         "values are quoted and unset inputs are skipped",
+			host:         "github.com",
+			ref:          "feature/it's",
+			inputKeys:    []string{"note", "version"},
+			userInputs:   map[string]string{"note": "deploy $HOME; now"},
+			wantDispatch: `gh dispatch run deploy.yml --ref 'feature/it'\''s' --input 'note=deploy $HOME; now' --repo user/repo`,
+			wantWorkflow: `gh workflow run deploy.yml --ref 'feature/it'\''s' -f 'note=deploy $HOME; now' --repo user/repo`,
+		},
+		{
+			name:         "other hosts are part of --repo",
+			host:         "ghes.example.com",
+			ref:          "v1.0.0",
+			wantDispatch: "gh dispatch run deploy.yml --ref v1.0.0 --repo ghes.example.com/user/repo",
+			wantWorkflow: "gh workflow run deploy.yml --ref v1.0.0 --repo ghes.example.com/user/repo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{
+				host:             tt.host,
+				owner:            "user",
+				repo:             "repo",
+				selectedWorkflow: item{fileName: "deploy.yml"},
+				selectedBranch:   item{title: tt.ref},
+				inputKeys:        tt.inputKeys,
+				userInputs:       tt.userInputs,
+			}
+
+			dispatchCmd, workflowCmd := m.reproduceCommands()
+			if dispatchCmd != tt.wantDispatch {
+				t.Errorf("dispatch command = %s, want %s", dispatchCmd, tt.wantDispatch)
+			}
+			if workflowCmd != tt.wantWorkflow {
+				t.Errorf("workflow command = %s, want %s", workflowCmd, tt.wantWorkflow)
+			}
+		})
+	}
+}
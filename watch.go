@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yanskun/gh-dispatch/internal/workflow"
+)
+
+// watchInterval は実行の状態をポーリングする間隔です
+const watchInterval = 3 * time.Second
+
+// runUpdateMsg はポーリングで取得した実行とジョブの状態です
+type runUpdateMsg struct {
+	run  *workflow.Run
+	jobs []workflow.Job
+	err  error
+}
+
+// pollRunMsg は次のポーリングのタイミングを知らせます
+type pollRunMsg struct{}
+
+// tickMsg は経過時間の表示を更新するタイミングを知らせます
+type tickMsg time.Time
+
+// pollRun は実行とジョブの状態を取得するコマンドを返します
+func pollRun(client workflow.RESTClient, owner, repo string, id int64) tea.Cmd {
+	return func() tea.Msg {
+		run, err := workflow.GetRun(client, owner, repo, id)
+		if err != nil {
+			return runUpdateMsg{err: err}
+		}
+		jobs, err := workflow.ListJobs(client, owner, repo, id)
+		if err != nil {
+			return runUpdateMsg{err: err}
+		}
+		return runUpdateMsg{run: run, jobs: jobs}
+	}
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// updateWatching は実行の監視中のメッセージを処理します
+func (m model) updateWatching(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			// 監視をやめても実行は続く
+			return m, tea.Quit
+		}
+	case runUpdateMsg:
+		if msg.err != nil {
+			m.watchErr = msg.err.Error()
+		} else {
+			m.watchErr = ""
+			m.run = msg.run
+			m.jobs = msg.jobs
+			if m.run.Completed() {
+				return m, tea.Quit
+			}
+		}
+		return m, tea.Tick(watchInterval, func(time.Time) tea.Msg { return pollRunMsg{} })
+	case pollRunMsg:
+		return m, pollRun(m.client, m.owner, m.repo, m.run.ID)
+	case tickMsg:
+		m.now = time.Time(msg)
+		return m, tick()
+	}
+	return m, nil
+}
+
+// watchView は実行のジョブとステップの状態を表示します
+func (m model) watchView() string {
+	var output strings.Builder
+
+	now := m.now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	output.WriteString(titleStyle.Render(fmt.Sprintf("Watching Run #%d", m.run.ID)))
+	output.WriteString("\n\n")
+
+	output.WriteString(labelStyle.Render("Workflow: "))
+	output.WriteString(valueStyle.Render(m.selectedWorkflow.title))
+	output.WriteString("\n")
+	output.WriteString(labelStyle.Render("Status: "))
+	output.WriteString(statusText(m.run.Status, m.run.Conclusion))
+	if !m.run.StartedAt.IsZero() {
+		output.WriteString(labelStyle.Render(" · " + elapsed(m.run.StartedAt, m.run.UpdatedAt, m.run.Completed(), now)))
+	}
+	output.WriteString("\n")
+	output.WriteString(labelStyle.Render("URL: "))
+	output.WriteString(m.run.HTMLURL)
+	output.WriteString("\n\n")
+
+	if len(m.jobs) == 0 {
+		output.WriteString(labelStyle.Render("Waiting for jobs..."))
+		output.WriteString("\n")
+	}
+	for _, job := range m.jobs {
+		output.WriteString(statusIcon(job.Status, job.Conclusion))
+		output.WriteString(" ")
+		output.WriteString(valueStyle.Render(job.Name))
+		if !job.StartedAt.IsZero() {
+			output.WriteString(labelStyle.Render(" " + elapsed(job.StartedAt, job.CompletedAt, job.Status == workflow.StatusCompleted, now)))
+		}
+		output.WriteString("\n")
+
+		// 成功したジョブのステップは省略する
+		if job.Status == workflow.StatusCompleted && job.Conclusion != "failure" {
+			continue
+		}
+		for _, step := range job.Steps {
+			output.WriteString("  ")
+			output.WriteString(statusIcon(step.Status, step.Conclusion))
+			output.WriteString(" ")
+			output.WriteString(step.Name)
+			if !step.StartedAt.IsZero() {
+				output.WriteString(labelStyle.Render(" " + elapsed(step.StartedAt, step.CompletedAt, step.Status == workflow.StatusCompleted, now)))
+			}
+			output.WriteString("\n")
+		}
+	}
+
+	if m.watchErr != "" {
+		output.WriteString("\n")
+		output.WriteString(errorStyle.Render("✗ " + m.watchErr))
+		output.WriteString("\n")
+	}
+
+	output.WriteString(hintStyle.Render("Press q to stop watching (the run keeps going)"))
+
+	return docStyle.Render(output.String())
+}
+
+// statusIcon は status と conclusion に応じたアイコンを返します
+func statusIcon(status, conclusion string) string {
+	switch status {
+	case workflow.StatusQueued, "waiting", "pending", "requested":
+		return labelStyle.Render("○")
+	case workflow.StatusInProgress:
+		return inputStyle.Render("●")
+	}
+	switch conclusion {
+	case "success":
+		return valueStyle.Render("✓")
+	case "skipped", "neutral":
+		return labelStyle.Render("-")
+	}
+	return errorStyle.Render("✗")
+}
+
+// statusText は status と conclusion を表示用の文字列にします
+func statusText(status, conclusion string) string {
+	if status == workflow.StatusCompleted {
+		return statusIcon(status, conclusion) + " " + conclusion
+	}
+	return statusIcon(status, conclusion) + " " + status
+}
+
+// elapsed は開始からの経過時間 (完了している場合は所要時間) を返します
+func elapsed(start, end time.Time, completed bool, now time.Time) string {
+	if !completed || end.IsZero() {
+		end = now
+	}
+	return end.Sub(start).Round(time.Second).String()
+}
+
+// waitForRun は実行が完了するまでポーリングし、ジョブの完了を標準出力に出力します
+// TUI を使わないスクリプト向けです
+func waitForRun(client workflow.RESTClient, owner, repo string, id int64) (*workflow.Run, error) {
+	reported := map[int64]bool{}
+	for {
+		run, err := workflow.GetRun(client, owner, repo, id)
+		if err != nil {
+			return nil, err
+		}
+		jobs, err := workflow.ListJobs(client, owner, repo, id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, job := range jobs {
+			if job.Status != workflow.StatusCompleted || reported[job.ID] {
+				continue
+			}
+			reported[job.ID] = true
+			fmt.Printf("%s %s (%s, %s)\n", statusIcon(job.Status, job.Conclusion), job.Name, job.Conclusion,
+				elapsed(job.StartedAt, job.CompletedAt, true, time.Now()))
+		}
+
+		if run.Completed() {
+			return run, nil
+		}
+		time.Sleep(watchInterval)
+	}
+}